@@ -8,22 +8,28 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/charmbracelet/bubbles/progress"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/pterm/pterm"
+
+	"github.com/HackerOS-Linux-System/Hacker-Package-Manager/internal/dep"
+	"github.com/HackerOS-Linux-System/Hacker-Package-Manager/internal/repoindex"
 )
 
 const (
 	repoURL     = "https://raw.githubusercontent.com/HackerOS-Linux-System/Hacker-Package-Manager/main/community/repo/repo.hacker"
+	repoSigURL  = repoURL + ".sig"
 	repoFile    = "/tmp/repo.hacker"
+	repoSigFile = "/tmp/repo.hacker.sig"
 	tmpDirBase  = "/tmp/community-packages"
 	templateURL = "https://github.com/Bytes-Repository/hpm-example-repo"
 )
 
-var repoMap map[string]string
+var repoMap map[string]repoindex.Entry
 
 func main() {
 	if len(os.Args) < 2 {
@@ -34,6 +40,17 @@ func main() {
 	cmd := os.Args[1]
 	args := os.Args[2:]
 
+	// trust is local-only (it just writes to TrustedKeyDir) so it runs
+	// before the network fetch every other command needs.
+	if cmd == "trust" {
+		if err := handleTrust(args); err != nil {
+			pterm.Error.Println("Trust failed:", err)
+			os.Exit(1)
+		}
+		pterm.Success.Println("Trust store updated.")
+		return
+	}
+
 	// Load repo map
 	err := loadRepoMap()
 	if err != nil {
@@ -64,6 +81,17 @@ func main() {
 		} else {
 			pterm.Success.Println("Remove completed successfully.")
 		}
+	case "verify":
+		if len(args) != 1 {
+			pterm.Error.Println("Usage: community verify {package}")
+			os.Exit(1)
+		}
+		err := handleVerify(args[0])
+		if err != nil {
+			pterm.Error.Println("Verify failed:", err)
+			os.Exit(1)
+		}
+		pterm.Success.Println("Verify completed successfully.")
 	case "clean":
 		err := handleClean()
 		if err != nil {
@@ -84,76 +112,68 @@ func main() {
 func printUsage() {
 	pterm.DefaultHeader.WithFullWidth().Println("Community Package Manager")
 	pterm.Println("Commands:")
-	pterm.Println("  install {package} - Install a package")
-	pterm.Println("  remove {package}  - Remove a package")
-	pterm.Println("  clean             - Clean temporary files")
-	pterm.Println("  template          - Show template repository link")
-	pterm.Println("  update            - Update (not implemented)")
+	pterm.Println("  install {package}      - Install a package")
+	pterm.Println("  remove {package}       - Remove a package")
+	pterm.Println("  verify {package}       - Re-check a package's commit pin and tree hash")
+	pterm.Println("  trust --add {keyfile}  - Trust an additional maintainer key")
+	pterm.Println("  clean                  - Clean temporary files")
+	pterm.Println("  template               - Show template repository link")
+	pterm.Println("  update                 - Update (not implemented)")
 }
 
+// loadRepoMap downloads (or reuses a cached) repo.hacker and its detached
+// signature, verifies the signature against the trust store before
+// touching the contents, and only then parses it into repoMap.
 func loadRepoMap() error {
-	// Check if repo file exists, otherwise download
 	if _, err := os.Stat(repoFile); os.IsNotExist(err) {
-		err := downloadRepoFile()
-		if err != nil {
+		if err := downloadRepoFile(); err != nil {
 			return err
 		}
 	}
 
-	file, err := os.Open(repoFile)
+	indexBytes, err := os.ReadFile(repoFile)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	repoMap = make(map[string]string)
-	scanner := bufio.NewScanner(file)
-	inArray := false
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "[" {
-			inArray = true
-			continue
-		}
-		if line == "]" {
-			inArray = false
-			continue
-		}
-		if inArray && strings.Contains(line, "->") {
-			parts := strings.SplitN(line, "->", 2)
-			if len(parts) == 2 {
-				key := strings.TrimSpace(parts[0])
-				value := strings.TrimSpace(parts[1])
-				// Remove trailing comma if present
-				if strings.HasSuffix(value, ",") {
-					value = strings.TrimSuffix(value, ",")
-				}
-				repoMap[key] = value
-			}
-		}
+	sigBytes, err := os.ReadFile(repoSigFile)
+	if err != nil {
+		return fmt.Errorf("reading repo.hacker.sig: %w", err)
 	}
 
-	if err := scanner.Err(); err != nil {
+	keyring, err := repoindex.LoadTrustStore()
+	if err != nil {
+		return fmt.Errorf("loading trust store: %w", err)
+	}
+	if err := repoindex.VerifyDetached(indexBytes, sigBytes, keyring); err != nil {
 		return err
 	}
 
-	return nil
+	repoMap, err = repoindex.Parse(strings.NewReader(string(indexBytes)))
+	return err
 }
 
 func downloadRepoFile() error {
 	pterm.Info.Println("Downloading repo.hacker...")
-	resp, err := http.Get(repoURL)
+	if err := fetchTo(repoURL, repoFile); err != nil {
+		return err
+	}
+
+	pterm.Info.Println("Downloading repo.hacker.sig...")
+	return fetchTo(repoSigURL, repoSigFile)
+}
+
+func fetchTo(url, dest string) error {
+	resp, err := http.Get(url)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download: %s", resp.Status)
+		return fmt.Errorf("failed to download %s: %s", url, resp.Status)
 	}
 
-	file, err := os.Create(repoFile)
+	file, err := os.Create(dest)
 	if err != nil {
 		return err
 	}
@@ -164,10 +184,13 @@ func downloadRepoFile() error {
 }
 
 func handleInstall(pkg string) error {
-	url, ok := repoMap[pkg]
+	entry, ok := repoMap[pkg]
 	if !ok {
 		return fmt.Errorf("package %s not found in repo", pkg)
 	}
+	if err := requirePin(pkg, entry); err != nil {
+		return err
+	}
 
 	tmpDir := filepath.Join(tmpDirBase, pkg)
 	err := os.MkdirAll(tmpDir, 0755)
@@ -175,9 +198,19 @@ func handleInstall(pkg string) error {
 		return err
 	}
 
-	pterm.Info.Println("Cloning repository:", url)
-	err = gitClone(url, tmpDir)
+	pterm.Info.Println("Cloning repository:", entry.URL)
+	if err := gitClone(entry.URL, tmpDir); err != nil {
+		return err
+	}
+	if err := repoindex.CheckoutPinned(tmpDir, entry); err != nil {
+		return fmt.Errorf("refusing to install %s: %w", pkg, err)
+	}
+
+	order, err := dep.Resolve([]dep.Package{{Name: pkg, Source: dep.Community}})
 	if err != nil {
+		return fmt.Errorf("resolving dependencies: %w", err)
+	}
+	if err := installPrereqs(order); err != nil {
 		return err
 	}
 
@@ -185,11 +218,36 @@ func handleInstall(pkg string) error {
 	return runScriptWithProgress(script)
 }
 
+// installPrereqs installs the apt-side dependencies a community package
+// declared before its install.hacker script runs, build-time deps first.
+func installPrereqs(order *dep.DepOrder) error {
+	if len(order.Missing) > 0 {
+		return fmt.Errorf("could not resolve dependencies: %s", strings.Join(order.Missing, ", "))
+	}
+
+	for _, group := range [][]dep.Package{order.RepoMake, order.Repo} {
+		for _, p := range group {
+			pterm.Info.Println("Installing dependency:", p.Name)
+			cmd := exec.Command("sudo", "apt-get", "install", "-y", p.Name)
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+			if err := cmd.Run(); err != nil {
+				return fmt.Errorf("installing dependency %s: %w", p.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
 func handleRemove(pkg string) error {
-	url, ok := repoMap[pkg]
+	entry, ok := repoMap[pkg]
 	if !ok {
 		return fmt.Errorf("package %s not found in repo", pkg)
 	}
+	if err := requirePin(pkg, entry); err != nil {
+		return err
+	}
 
 	tmpDir := filepath.Join(tmpDirBase, pkg)
 	err := os.MkdirAll(tmpDir, 0755)
@@ -197,16 +255,63 @@ func handleRemove(pkg string) error {
 		return err
 	}
 
-	pterm.Info.Println("Cloning repository:", url)
-	err = gitClone(url, tmpDir)
-	if err != nil {
+	pterm.Info.Println("Cloning repository:", entry.URL)
+	if err := gitClone(entry.URL, tmpDir); err != nil {
 		return err
 	}
+	if err := repoindex.CheckoutPinned(tmpDir, entry); err != nil {
+		return fmt.Errorf("refusing to remove %s: %w", pkg, err)
+	}
 
 	script := filepath.Join(tmpDir, "remove.hacker")
 	return runScriptWithProgress(script)
 }
 
+// handleVerify re-clones pkg into a scratch directory and re-checks its
+// commit pin and tree hash without running any of its scripts, so a user
+// can confirm a package still matches its published pin before trusting
+// an existing checkout.
+func handleVerify(pkg string) error {
+	entry, ok := repoMap[pkg]
+	if !ok {
+		return fmt.Errorf("package %s not found in repo", pkg)
+	}
+	if err := requirePin(pkg, entry); err != nil {
+		return err
+	}
+
+	verifyDir := filepath.Join(tmpDirBase, "verify-"+pkg)
+	if err := os.RemoveAll(verifyDir); err != nil {
+		return err
+	}
+	if err := gitClone(entry.URL, verifyDir); err != nil {
+		return err
+	}
+	defer os.RemoveAll(verifyDir)
+
+	return repoindex.CheckoutPinned(verifyDir, entry)
+}
+
+// requirePin refuses an entry published without a commit or tree-hash pin,
+// closing the same force-push hole for install/remove that handleVerify
+// already guarded: CheckoutPinned is a no-op when both are empty, which
+// would otherwise run install.hacker/remove.hacker against whatever the
+// clone's HEAD happens to be at fetch time.
+func requirePin(pkg string, entry repoindex.Entry) error {
+	if entry.Commit == "" && entry.SHA256 == "" {
+		return fmt.Errorf("package %s is not pinned in repo.hacker", pkg)
+	}
+	return nil
+}
+
+// handleTrust implements `community trust --add <keyfile>`.
+func handleTrust(args []string) error {
+	if len(args) != 2 || args[0] != "--add" {
+		return fmt.Errorf("usage: community trust --add {keyfile}")
+	}
+	return repoindex.AddTrustedKey(args[1])
+}
+
 func gitClone(url, dir string) error {
 	cmd := exec.Command("git", "clone", url, dir)
 	cmd.Stdout = os.Stdout
@@ -214,6 +319,11 @@ func gitClone(url, dir string) error {
 	return cmd.Run()
 }
 
+// hpmProgressRe matches the `# hpm-progress: N/M <label>` convention a
+// .hacker script can echo to advance the bar past a fixed step rather than
+// leaving it to guess from raw output.
+var hpmProgressRe = regexp.MustCompile(`^#\s*hpm-progress:\s*(\d+)/(\d+)\s*(.*)$`)
+
 func runScriptWithProgress(script string) error {
 	if _, err := os.Stat(script); os.IsNotExist(err) {
 		return fmt.Errorf("script %s not found", script)
@@ -221,23 +331,55 @@ func runScriptWithProgress(script string) error {
 
 	pterm.Info.Println("Running script:", script)
 
-	// Simulate progress for script execution (since we can't hook into bash progress easily)
 	p := tea.NewProgram(initialModel())
+
+	var scriptErr error
 	go func() {
-		// Simulate 10 seconds of work
-		time.Sleep(10 * time.Second)
-		p.Quit()
+		scriptErr = streamScript(script, p)
+		p.Send(scriptDoneMsg{err: scriptErr})
 	}()
 
 	if _, err := p.Run(); err != nil {
 		return err
 	}
+	return scriptErr
+}
 
-	// Actually run the script
+// streamScript runs script, printing its output line by line and feeding
+// any `# hpm-progress:` lines to p as scriptProgressMsg updates instead of
+// printing them.
+func streamScript(script string, p *tea.Program) error {
 	cmd := exec.Command("bash", script)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- cmd.Wait()
+		pw.Close()
+	}()
+
+	scanner := bufio.NewScanner(pr)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := hpmProgressRe.FindStringSubmatch(line); m != nil {
+			done, _ := strconv.Atoi(m[1])
+			total, _ := strconv.Atoi(m[2])
+			if total > 0 {
+				p.Send(scriptProgressMsg{percent: float64(done) / float64(total), label: strings.TrimSpace(m[3])})
+			}
+			continue
+		}
+		fmt.Println(line)
+	}
+
+	return <-waitErr
 }
 
 func handleClean() error {
@@ -249,25 +391,42 @@ func handleTemplate() {
 	pterm.Info.Println("Template repository:", templateURL)
 }
 
-// Bubble Tea Progress Model
+// Bubble Tea Progress Model, driven by streamScript's scriptProgressMsg
+// and scriptDoneMsg instead of a fixed-duration tick.
 type model struct {
 	progress progress.Model
+	label    string
+	err      error
+}
+
+// scriptProgressMsg is sent for each `# hpm-progress: N/M <label>` line a
+// script echoes; percent is already normalized to [0, 1].
+type scriptProgressMsg struct {
+	percent float64
+	label   string
 }
 
+// scriptDoneMsg is sent once the script process exits, successfully or not.
+type scriptDoneMsg struct{ err error }
+
 func initialModel() model {
 	return model{
 		progress: progress.New(progress.WithDefaultGradient()),
+		label:    "Running script...",
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return tickCmd()
+	return nil
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		return m, tea.Quit
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		return m, nil
 
 	case tea.WindowSizeMsg:
 		m.progress.Width = msg.Width - 4
@@ -276,12 +435,20 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
-	case tickMsg:
-		if m.progress.Percent() >= 1.0 {
-			return m, tea.Quit
-		}
-		cmd := m.progress.IncrPercent(0.25)
-		return m, tea.Batch(tickCmd(), cmd)
+	case scriptProgressMsg:
+		m.label = msg.label
+		cmd := m.progress.SetPercent(msg.percent)
+		return m, cmd
+
+	case scriptDoneMsg:
+		m.err = msg.err
+		cmd := m.progress.SetPercent(1.0)
+		return m, tea.Batch(cmd, tea.Quit)
+
+	case progress.FrameMsg:
+		progressModel, cmd := m.progress.Update(msg)
+		m.progress = progressModel.(progress.Model)
+		return m, cmd
 	}
 
 	return m, nil
@@ -290,13 +457,5 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m model) View() string {
 	return "\n" +
 		m.progress.View() + "\n\n" +
-		"Running script... Press any key to quit\n"
-}
-
-type tickMsg time.Time
-
-func tickCmd() tea.Cmd {
-	return tea.Tick(time.Second*1, func(t time.Time) tea.Msg {
-		return tickMsg(t)
-	})
+		m.label + "\n"
 }
@@ -0,0 +1,176 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// AptBackend drives apt/apt-cache directly instead of the TUI shelling out
+// inline.
+type AptBackend struct{}
+
+func init() {
+	Register(AptBackend{})
+}
+
+func (AptBackend) Name() string { return "APT" }
+
+func (AptBackend) Search(query string) ([]Package, error) {
+	out, err := exec.Command("apt-cache", "search", "--names-only", query).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []Package
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, " - ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		pkgs = append(pkgs, Package{Name: strings.TrimSpace(parts[0]), Desc: strings.TrimSpace(parts[1])})
+	}
+	return pkgs, nil
+}
+
+func (AptBackend) Install(ctx context.Context, pkg Package, opts InstallOptions) (<-chan Event, error) {
+	return streamAptStatus(ctx, "install", pkg.Name, "install", "-y", pkg.Name)
+}
+
+func (AptBackend) Remove(ctx context.Context, pkg Package, opts InstallOptions) (<-chan Event, error) {
+	return streamAptStatus(ctx, "remove", pkg.Name, "remove", "-y", pkg.Name)
+}
+
+// streamAptStatus runs apt-get with APT::Status-Fd wired to an extra pipe,
+// so progress comes from apt's own machine-readable dlstatus:/pmstatus:
+// lines instead of guessing from stdout, the same way streamCommand's
+// callers used to just wait for the process to exit.
+//
+// sudo closes every fd >= 3 before exec'ing the target command unless told
+// otherwise, which would silently close our status pipe (fd 3) before apt
+// ever writes to it; -C4 raises that closefrom threshold to 4 so fd 3
+// survives. sudo only honors -C when the sudoers entry for this command
+// sets `closefrom_override` (e.g. `Defaults:hpm closefrom_override`) —
+// without it sudo refuses -C and exits, so hpm's sudoers setup must grant
+// that option alongside NOPASSWD for apt-get.
+func streamAptStatus(ctx context.Context, stage, pkgName string, aptArgs ...string) (<-chan Event, error) {
+	statusR, statusW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	args := append([]string{"-C4", "apt-get", "-o", "APT::Status-Fd=3"}, aptArgs...)
+	cmd := exec.CommandContext(ctx, "sudo", args...)
+	cmd.ExtraFiles = []*os.File{statusW}
+
+	outR, outW := io.Pipe()
+	cmd.Stdout = outW
+	cmd.Stderr = outW
+
+	if err := cmd.Start(); err != nil {
+		statusW.Close()
+		statusR.Close()
+		return nil, err
+	}
+	statusW.Close() // apt holds its own copy of fd 3 open; ours must close for EOF to fire
+
+	events := make(chan Event)
+
+	go func() {
+		defer close(events)
+		defer statusR.Close()
+
+		waitErr := make(chan error, 1)
+		go func() {
+			waitErr <- cmd.Wait()
+			outW.Close()
+		}()
+
+		logDone := make(chan struct{})
+		go func() {
+			defer close(logDone)
+			scanner := bufio.NewScanner(outR)
+			for scanner.Scan() {
+				events <- Event{Stage: stage, Package: pkgName, Log: scanner.Text()}
+			}
+		}()
+
+		statusScanner := bufio.NewScanner(statusR)
+		for statusScanner.Scan() {
+			if ev, ok := parseAptStatusLine(stage, statusScanner.Text()); ok {
+				events <- ev
+			}
+		}
+
+		<-logDone
+		events <- Event{Stage: stage, Package: pkgName, Percent: 100, Done: true, Err: <-waitErr}
+	}()
+
+	return events, nil
+}
+
+// parseAptStatusLine turns one `dlstatus:` or `pmstatus:` status-fd line
+// into an Event. The format is `<kind>:<pkg>:<percent>:<message>`; percent
+// tracks apt's overall transaction, not this one package, but it's the only
+// number apt reports so it drives the progress bar directly.
+func parseAptStatusLine(stage, line string) (Event, bool) {
+	parts := strings.SplitN(line, ":", 4)
+	if len(parts) != 4 || (parts[0] != "dlstatus" && parts[0] != "pmstatus") {
+		return Event{}, false
+	}
+
+	percent, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return Event{}, false
+	}
+
+	return Event{Stage: stage, Package: parts[1], Percent: percent, Log: parts[3]}, true
+}
+
+func (AptBackend) Info(pkg Package) (PackageInfo, error) {
+	out, err := exec.Command("apt-cache", "show", pkg.Name).Output()
+	if err != nil {
+		return PackageInfo{}, err
+	}
+
+	info := PackageInfo{Name: pkg.Name}
+	for _, line := range strings.Split(string(out), "\n") {
+		switch {
+		case strings.HasPrefix(line, "Version:"):
+			info.Version = strings.TrimSpace(strings.TrimPrefix(line, "Version:"))
+		case strings.HasPrefix(line, "Description:"):
+			info.Description = strings.TrimSpace(strings.TrimPrefix(line, "Description:"))
+		}
+	}
+
+	if _, err := exec.Command("dpkg-query", "-W", pkg.Name).Output(); err == nil {
+		info.Installed = true
+	}
+
+	return info, nil
+}
+
+func (AptBackend) List() ([]Package, error) {
+	out, err := exec.Command("apt-mark", "showmanual").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []Package
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		pkgs = append(pkgs, Package{Name: line})
+	}
+	return pkgs, nil
+}
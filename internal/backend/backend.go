@@ -0,0 +1,138 @@
+// Package backend defines the pluggable interface each package source
+// (apt, snap, flatpak, the community script tool, and eventually others
+// like pacman or dnf) implements, plus a global registry the TUI iterates
+// instead of hardcoding a switch arm per source.
+package backend
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os/exec"
+	"sort"
+	"sync"
+)
+
+// Package is a single result from a backend's Search/List.
+type Package struct {
+	Name string
+	Desc string
+}
+
+// PackageInfo is the detailed view returned by Info.
+type PackageInfo struct {
+	Name        string
+	Version     string
+	Description string
+	Installed   bool
+}
+
+// InstallOptions carries per-transaction flags down into a backend. It is
+// empty today but gives Install/Remove a stable signature to grow into
+// (e.g. AssumeYes, Simulate) without breaking the interface.
+type InstallOptions struct{}
+
+// Event is one step of an in-progress Install/Remove, streamed back to the
+// caller so a UI can render live progress instead of blocking on a fixed
+// timer. A backend closes the channel after sending the final event, which
+// has Done set to true and Err set if the transaction failed. BytesDone and
+// BytesTotal are best-effort: they're only set when the underlying tool's
+// output actually reports a byte count, and are 0 otherwise.
+type Event struct {
+	Stage      string
+	Package    string
+	Percent    float64
+	BytesDone  int64
+	BytesTotal int64
+	Log        string
+	Done       bool
+	Err        error
+}
+
+// Backend is the contract every package source implements.
+type Backend interface {
+	// Name is the backend's canonical, upper-case identifier (e.g. "APT"),
+	// matching the tui.Source values so results can be filtered by source.
+	Name() string
+	Search(query string) ([]Package, error)
+	Install(ctx context.Context, pkg Package, opts InstallOptions) (<-chan Event, error)
+	Remove(ctx context.Context, pkg Package, opts InstallOptions) (<-chan Event, error)
+	Info(pkg Package) (PackageInfo, error)
+	List() ([]Package, error)
+}
+
+var (
+	mu       sync.Mutex
+	backends = map[string]Backend{}
+)
+
+// Register adds a backend to the global registry, keyed by its Name(). It
+// is meant to be called from a backend implementation's init().
+func Register(b Backend) {
+	mu.Lock()
+	defer mu.Unlock()
+	backends[b.Name()] = b
+}
+
+// Get looks up a backend by name (case-sensitive, matching Name()).
+func Get(name string) (Backend, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	b, ok := backends[name]
+	return b, ok
+}
+
+// Enabled returns every registered backend, sorted by name for stable
+// iteration order (used by the TUI to build its source filter).
+func Enabled() []Backend {
+	mu.Lock()
+	defer mu.Unlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make([]Backend, 0, len(names))
+	for _, name := range names {
+		out = append(out, backends[name])
+	}
+	return out
+}
+
+// streamCommand runs cmd, forwarding its combined stdout+stderr line by
+// line as Log events under stage, and finishes with a single Done event
+// carrying cmd's exit error, if any. CommunityBackend's Install/Remove and
+// FlatpakBackend's Remove use it; apt, snap and flatpak installs stream
+// through their own progress-parsing helpers instead.
+func streamCommand(stage, pkgName string, cmd *exec.Cmd) <-chan Event {
+	events := make(chan Event)
+
+	go func() {
+		defer close(events)
+
+		pr, pw := io.Pipe()
+		cmd.Stdout = pw
+		cmd.Stderr = pw
+
+		if err := cmd.Start(); err != nil {
+			events <- Event{Stage: stage, Package: pkgName, Err: err, Done: true}
+			return
+		}
+
+		waitErr := make(chan error, 1)
+		go func() {
+			waitErr <- cmd.Wait()
+			pw.Close()
+		}()
+
+		scanner := bufio.NewScanner(pr)
+		for scanner.Scan() {
+			events <- Event{Stage: stage, Package: pkgName, Log: scanner.Text()}
+		}
+
+		events <- Event{Stage: stage, Package: pkgName, Percent: 100, Done: true, Err: <-waitErr}
+	}()
+
+	return events
+}
@@ -0,0 +1,123 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+
+	"github.com/HackerOS-Linux-System/Hacker-Package-Manager/internal/repoindex"
+)
+
+var errPackageNotFound = errors.New("package not found in community repo")
+
+// communityRepoURL mirrors repoURL in community/source-code/main.go: the
+// index this backend searches against.
+const communityRepoURL = "https://raw.githubusercontent.com/HackerOS-Linux-System/Hacker-Package-Manager/main/community/repo/repo.hacker"
+const communityRepoSigURL = communityRepoURL + ".sig"
+
+// CommunityBackend wraps the standalone `community` CLI tool (see
+// community/source-code/main.go) so it plugs into the same Backend
+// interface as apt/snap/flatpak: Search reads the repo index directly,
+// while Install/Remove shell out to the `community` binary, which owns
+// cloning, pin verification, and running the package's .hacker scripts.
+type CommunityBackend struct{}
+
+func init() {
+	Register(CommunityBackend{})
+}
+
+func (CommunityBackend) Name() string { return "COMMUNITY" }
+
+func (CommunityBackend) Search(query string) ([]Package, error) {
+	repoMap, err := fetchRepoMap()
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []Package
+	for name, entry := range repoMap {
+		if query == "" || strings.Contains(name, query) {
+			pkgs = append(pkgs, Package{Name: name, Desc: entry.URL})
+		}
+	}
+	return pkgs, nil
+}
+
+func (CommunityBackend) Install(ctx context.Context, pkg Package, opts InstallOptions) (<-chan Event, error) {
+	cmd := exec.CommandContext(ctx, "community", "install", pkg.Name)
+	return streamCommand("install", pkg.Name, cmd), nil
+}
+
+func (CommunityBackend) Remove(ctx context.Context, pkg Package, opts InstallOptions) (<-chan Event, error) {
+	cmd := exec.CommandContext(ctx, "community", "remove", pkg.Name)
+	return streamCommand("remove", pkg.Name, cmd), nil
+}
+
+func (CommunityBackend) Info(pkg Package) (PackageInfo, error) {
+	repoMap, err := fetchRepoMap()
+	if err != nil {
+		return PackageInfo{}, err
+	}
+
+	entry, ok := repoMap[pkg.Name]
+	if !ok {
+		return PackageInfo{}, errPackageNotFound
+	}
+	return PackageInfo{Name: pkg.Name, Description: entry.URL}, nil
+}
+
+func (CommunityBackend) List() ([]Package, error) {
+	repoMap, err := fetchRepoMap()
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs := make([]Package, 0, len(repoMap))
+	for name, entry := range repoMap {
+		pkgs = append(pkgs, Package{Name: name, Desc: entry.URL})
+	}
+	return pkgs, nil
+}
+
+// fetchRepoMap downloads repo.hacker and its detached signature, verifies
+// the signature against the trust store, and parses the index the same
+// way community/source-code/main.go's loadRepoMap does. A search or Info
+// call never touches an index it can't verify.
+func fetchRepoMap() (map[string]repoindex.Entry, error) {
+	indexBytes, err := fetchBody(communityRepoURL)
+	if err != nil {
+		return nil, err
+	}
+	sigBytes, err := fetchBody(communityRepoSigURL)
+	if err != nil {
+		return nil, err
+	}
+
+	keyring, err := repoindex.LoadTrustStore()
+	if err != nil {
+		return nil, err
+	}
+	if err := repoindex.VerifyDetached(indexBytes, sigBytes, keyring); err != nil {
+		return nil, err
+	}
+
+	return repoindex.Parse(strings.NewReader(string(indexBytes)))
+}
+
+func fetchBody(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
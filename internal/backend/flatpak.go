@@ -0,0 +1,163 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FlatpakBackend drives the flatpak CLI directly.
+type FlatpakBackend struct{}
+
+func init() {
+	Register(FlatpakBackend{})
+}
+
+func (FlatpakBackend) Name() string { return "FLATPAK" }
+
+func (FlatpakBackend) Search(query string) ([]Package, error) {
+	out, err := exec.Command("flatpak", "search", query).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []Package
+	lines := strings.Split(string(out), "\n")
+	start := 0
+	if len(lines) > 0 && strings.Contains(lines[0], "Name") {
+		start = 1
+	}
+	for _, line := range lines[start:] {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.Split(line, "\t")
+		if len(parts) < 3 {
+			continue
+		}
+		pkgs = append(pkgs, Package{Name: parts[2], Desc: fmt.Sprintf("%s - %s", parts[0], parts[1])})
+	}
+	return pkgs, nil
+}
+
+func (FlatpakBackend) Install(ctx context.Context, pkg Package, opts InstallOptions) (<-chan Event, error) {
+	cmd := exec.CommandContext(ctx, "sudo", "flatpak", "install", "--assumeyes", "--ostree-verbose", pkg.Name)
+	return streamFlatpakProgress("install", pkg.Name, cmd), nil
+}
+
+func (FlatpakBackend) Remove(ctx context.Context, pkg Package, opts InstallOptions) (<-chan Event, error) {
+	cmd := exec.CommandContext(ctx, "sudo", "flatpak", "uninstall", "--assumeyes", pkg.Name)
+	return streamCommand("remove", pkg.Name, cmd), nil
+}
+
+var (
+	flatpakPercentRe = regexp.MustCompile(`(\d{1,3})%`)
+	flatpakBytesRe   = regexp.MustCompile(`([\d.]+)\s*([kMG]?B)\s*/\s*([\d.]+)\s*([kMG]?B)`)
+)
+
+// streamFlatpakProgress behaves like streamCommand but additionally scans
+// each --ostree-verbose line for a `NN%` marker (and, when present, a
+// `done/total` byte pair), since flatpak has no separate machine-readable
+// status channel the way apt's Status-Fd does.
+func streamFlatpakProgress(stage, pkgName string, cmd *exec.Cmd) <-chan Event {
+	events := make(chan Event)
+
+	go func() {
+		defer close(events)
+
+		pr, pw := io.Pipe()
+		cmd.Stdout = pw
+		cmd.Stderr = pw
+
+		if err := cmd.Start(); err != nil {
+			events <- Event{Stage: stage, Package: pkgName, Err: err, Done: true}
+			return
+		}
+
+		waitErr := make(chan error, 1)
+		go func() {
+			waitErr <- cmd.Wait()
+			pw.Close()
+		}()
+
+		scanner := bufio.NewScanner(pr)
+		for scanner.Scan() {
+			events <- parseFlatpakProgressLine(stage, pkgName, scanner.Text())
+		}
+
+		events <- Event{Stage: stage, Package: pkgName, Percent: 100, Done: true, Err: <-waitErr}
+	}()
+
+	return events
+}
+
+func parseFlatpakProgressLine(stage, pkgName, line string) Event {
+	ev := Event{Stage: stage, Package: pkgName, Log: line}
+
+	if m := flatpakPercentRe.FindStringSubmatch(line); m != nil {
+		if pct, err := strconv.ParseFloat(m[1], 64); err == nil {
+			ev.Percent = pct
+		}
+	}
+	if m := flatpakBytesRe.FindStringSubmatch(line); m != nil {
+		// flatpak prints decimal (SI) sizes, e.g. "1.2 MB"; scale to bytes.
+		toBytes := func(v float64, unit string) int64 {
+			switch unit {
+			case "kB":
+				v *= 1e3
+			case "MB":
+				v *= 1e6
+			case "GB":
+				v *= 1e9
+			}
+			return int64(v)
+		}
+		if done, err := strconv.ParseFloat(m[1], 64); err == nil {
+			ev.BytesDone = toBytes(done, m[2])
+		}
+		if total, err := strconv.ParseFloat(m[3], 64); err == nil {
+			ev.BytesTotal = toBytes(total, m[4])
+		}
+	}
+
+	return ev
+}
+
+func (FlatpakBackend) Info(pkg Package) (PackageInfo, error) {
+	out, err := exec.Command("flatpak", "info", pkg.Name).Output()
+	if err != nil {
+		return PackageInfo{}, err
+	}
+
+	info := PackageInfo{Name: pkg.Name, Installed: true}
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Version:") {
+			info.Version = strings.TrimSpace(strings.TrimPrefix(line, "Version:"))
+		}
+	}
+	return info, nil
+}
+
+func (FlatpakBackend) List() ([]Package, error) {
+	out, err := exec.Command("flatpak", "list", "--columns=application").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []Package
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		pkgs = append(pkgs, Package{Name: line})
+	}
+	return pkgs, nil
+}
@@ -0,0 +1,188 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// snapChangePollInterval is how often streamSnapChange re-polls an
+// in-flight change; snap has no push-based progress channel, so this is a
+// deliberate trade-off between responsiveness and spamming snapd.
+const snapChangePollInterval = 500 * time.Millisecond
+
+// SnapBackend drives the snap CLI directly.
+type SnapBackend struct{}
+
+func init() {
+	Register(SnapBackend{})
+}
+
+func (SnapBackend) Name() string { return "SNAP" }
+
+func (SnapBackend) Search(query string) ([]Package, error) {
+	out, err := exec.Command("snap", "find", query).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []Package
+	lines := strings.Split(string(out), "\n")
+	start := 0
+	if len(lines) > 0 && strings.Contains(lines[0], "Name") {
+		start = 1
+	}
+	for _, line := range lines[start:] {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		pkgs = append(pkgs, Package{Name: fields[0], Desc: strings.Join(fields[4:], " ")})
+	}
+	return pkgs, nil
+}
+
+func (SnapBackend) Install(ctx context.Context, pkg Package, opts InstallOptions) (<-chan Event, error) {
+	return streamSnapChange(ctx, "install", "install", pkg.Name)
+}
+
+func (SnapBackend) Remove(ctx context.Context, pkg Package, opts InstallOptions) (<-chan Event, error) {
+	return streamSnapChange(ctx, "remove", "remove", pkg.Name)
+}
+
+// snapChangeStatus is the subset of `snap debug api /v2/changes/<id>` we
+// care about: whether the change is finished, its error if any, and each
+// task's done/total progress, which snapd reports in work units rather
+// than bytes or a single percent.
+type snapChangeStatus struct {
+	Result struct {
+		Ready bool   `json:"ready"`
+		Err   string `json:"err"`
+		Tasks []struct {
+			Summary  string `json:"summary"`
+			Status   string `json:"status"`
+			Progress struct {
+				Done  int `json:"done"`
+				Total int `json:"total"`
+			} `json:"progress"`
+		} `json:"tasks"`
+	} `json:"result"`
+}
+
+// streamSnapChange kicks off an async snap change (--no-wait prints a
+// change ID and returns immediately) and polls `snap debug api` for its
+// status, since the plain CLI has no way to stream progress for an
+// in-flight change the way apt's Status-Fd or flatpak's verbose log do.
+func streamSnapChange(ctx context.Context, stage, verb, pkgName string) (<-chan Event, error) {
+	out, err := exec.CommandContext(ctx, "sudo", "snap", verb, "--no-wait", pkgName).Output()
+	if err != nil {
+		return nil, err
+	}
+	changeID := strings.TrimSpace(string(out))
+
+	events := make(chan Event)
+	go pollSnapChange(ctx, changeID, stage, pkgName, events)
+	return events, nil
+}
+
+func pollSnapChange(ctx context.Context, changeID, stage, pkgName string, events chan<- Event) {
+	defer close(events)
+
+	for {
+		out, err := exec.CommandContext(ctx, "snap", "debug", "api", "/v2/changes/"+changeID).Output()
+		if err != nil {
+			events <- Event{Stage: stage, Package: pkgName, Err: err, Done: true}
+			return
+		}
+
+		var status snapChangeStatus
+		if err := json.Unmarshal(out, &status); err != nil {
+			events <- Event{Stage: stage, Package: pkgName, Err: err, Done: true}
+			return
+		}
+
+		var done, total int
+		var summary string
+		for _, t := range status.Result.Tasks {
+			done += t.Progress.Done
+			total += t.Progress.Total
+			if t.Status == "Doing" {
+				summary = t.Summary
+			}
+		}
+
+		percent := 0.0
+		if total > 0 {
+			percent = float64(done) / float64(total) * 100
+		}
+		events <- Event{Stage: stage, Package: pkgName, Percent: percent, Log: summary}
+
+		if status.Result.Ready {
+			var doneErr error
+			if status.Result.Err != "" {
+				doneErr = fmt.Errorf("snap change %s: %s", changeID, status.Result.Err)
+			}
+			events <- Event{Stage: stage, Package: pkgName, Percent: 100, Done: true, Err: doneErr}
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			events <- Event{Stage: stage, Package: pkgName, Err: ctx.Err(), Done: true}
+			return
+		case <-time.After(snapChangePollInterval):
+		}
+	}
+}
+
+func (SnapBackend) Info(pkg Package) (PackageInfo, error) {
+	out, err := exec.Command("snap", "info", pkg.Name).Output()
+	if err != nil {
+		return PackageInfo{}, err
+	}
+
+	info := PackageInfo{Name: pkg.Name}
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "summary:"):
+			info.Description = strings.TrimSpace(strings.TrimPrefix(line, "summary:"))
+		case strings.HasPrefix(line, "installed:"):
+			info.Installed = true
+			fields := strings.Fields(strings.TrimPrefix(line, "installed:"))
+			if len(fields) > 0 {
+				info.Version = fields[0]
+			}
+		}
+	}
+	return info, nil
+}
+
+func (SnapBackend) List() ([]Package, error) {
+	out, err := exec.Command("snap", "list").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []Package
+	lines := strings.Split(string(out), "\n")
+	start := 0
+	if len(lines) > 0 && strings.Contains(lines[0], "Name") {
+		start = 1
+	}
+	for _, line := range lines[start:] {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		pkgs = append(pkgs, Package{Name: fields[0]})
+	}
+	return pkgs, nil
+}
@@ -0,0 +1,170 @@
+// Package db maintains a local record of every package hpm has installed,
+// analogous to pacman's local database. It gives orphan detection,
+// history, and rollback something to work from instead of re-deriving
+// install state from each backend on every call.
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Reason records why a package is installed, mirroring apt/pacman's
+// explicit-vs-automatic distinction: only Dependency-reason packages are
+// eligible to be reported as orphans.
+type Reason string
+
+const (
+	Explicit   Reason = "explicit"
+	Dependency Reason = "dependency"
+)
+
+// Record is one installed package as tracked by hpm.
+type Record struct {
+	Name          string    `json:"name"`
+	Source        string    `json:"source"`
+	Version       string    `json:"version,omitempty"`
+	Reason        Reason    `json:"reason"`
+	InstalledAt   time.Time `json:"installed_at"`
+	TransactionID string    `json:"transaction_id"`
+}
+
+// TxPackage identifies one package touched by a Transaction. Reason is the
+// Record's reason at the time it was touched, carried here specifically so
+// rolling back a "remove" transaction can restore the original
+// Explicit/Dependency reason instead of guessing.
+type TxPackage struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Reason Reason `json:"reason,omitempty"`
+}
+
+// Transaction is a group of installs or removals hpm performed together,
+// e.g. a dependency-ordered install. `hpm rollback <txid>` reverses one.
+type Transaction struct {
+	ID        string      `json:"id"`
+	Action    string      `json:"action"` // "install" or "remove"
+	Packages  []TxPackage `json:"packages"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// DB is the parsed contents of installed.json.
+type DB struct {
+	Packages     map[string]Record `json:"packages"` // keyed by "source:name"
+	Transactions []Transaction     `json:"transactions"`
+
+	path string
+}
+
+func recordKey(source, name string) string {
+	return source + ":" + name
+}
+
+// Path returns ~/.local/share/hpm/installed.json, creating its parent
+// directory if it doesn't exist yet.
+func Path() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(home, ".local", "share", "hpm")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "installed.json"), nil
+}
+
+// Load reads the database from disk, returning an empty one if it hasn't
+// been created yet.
+func Load() (*DB, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	d := &DB{Packages: map[string]Record{}, path: path}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return d, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	d.path = path
+	if d.Packages == nil {
+		d.Packages = map[string]Record{}
+	}
+	return d, nil
+}
+
+// Save writes the database back to disk as indented JSON.
+func (d *DB) Save() error {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(d.path, data, 0644)
+}
+
+// NewTransactionID returns an identifier unique enough to key a
+// transaction, derived from the current time.
+func NewTransactionID() string {
+	return fmt.Sprintf("tx-%d", time.Now().UnixNano())
+}
+
+// RecordInstall marks name/source as installed under transaction txID and
+// appends it to that transaction's package list.
+func (d *DB) RecordInstall(name, source, version string, reason Reason, txID string) {
+	d.Packages[recordKey(source, name)] = Record{
+		Name:          name,
+		Source:        source,
+		Version:       version,
+		Reason:        reason,
+		InstalledAt:   time.Now(),
+		TransactionID: txID,
+	}
+	d.appendToTransaction(txID, "install", TxPackage{Name: name, Source: source, Reason: reason})
+}
+
+// RecordRemove drops name/source from the database and appends it to
+// transaction txID's package list, preserving its reason at the time of
+// removal so a later rollback can restore it rather than assuming
+// Dependency.
+func (d *DB) RecordRemove(name, source, txID string) {
+	key := recordKey(source, name)
+	reason := d.Packages[key].Reason
+	delete(d.Packages, key)
+	d.appendToTransaction(txID, "remove", TxPackage{Name: name, Source: source, Reason: reason})
+}
+
+func (d *DB) appendToTransaction(txID, action string, pkg TxPackage) {
+	for i := range d.Transactions {
+		if d.Transactions[i].ID == txID && d.Transactions[i].Action == action {
+			d.Transactions[i].Packages = append(d.Transactions[i].Packages, pkg)
+			return
+		}
+	}
+	d.Transactions = append(d.Transactions, Transaction{
+		ID:        txID,
+		Action:    action,
+		Packages:  []TxPackage{pkg},
+		Timestamp: time.Now(),
+	})
+}
+
+// Transaction looks up a past transaction by ID.
+func (d *DB) Transaction(id string) (Transaction, bool) {
+	for _, t := range d.Transactions {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return Transaction{}, false
+}
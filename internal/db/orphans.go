@@ -0,0 +1,52 @@
+package db
+
+import "github.com/HackerOS-Linux-System/Hacker-Package-Manager/internal/dep"
+
+// depPackageFor maps a backend name (as stored in Record.Source) to a
+// dep.Package, matching toDepPackage in the TUI: only community `.hacker`
+// scripts are their own Community category, everything else is Repo with
+// Backend set to the real backend so dependenciesFor queries it correctly.
+func depPackageFor(name, source string) dep.Package {
+	if source == "COMMUNITY" {
+		return dep.Package{Name: name, Source: dep.Community}
+	}
+	return dep.Package{Name: name, Source: dep.Repo, Backend: source}
+}
+
+// Orphans returns every Dependency-reason APT package that the transitive
+// dependency closure of every currently-Explicit APT package no longer
+// needs, the same notion `pacman -Qtd` reports for pacman's local database.
+//
+// This is apt-only. A trustworthy cross-backend check needs each backend's
+// own reverse-dependency story — apt-mark showauto + apt-cache rdepends for
+// apt, `flatpak uninstall --unused --dry-run` for flatpak, disabled/unused
+// revisions for snap — and dep.Resolve only knows how to walk apt's graph
+// today (dependenciesFor in dep.go returns no dependencies for a snap- or
+// flatpak-backed Repo package). Until snap/flatpak get their own check, a Dependency record from
+// those backends is left out of both the "needed" set and the orphan list
+// rather than being reported unreliably.
+func (d *DB) Orphans() ([]Record, error) {
+	needed := map[string]bool{}
+	for _, rec := range d.Packages {
+		if rec.Reason != Explicit || rec.Source != "APT" {
+			continue
+		}
+		order, err := dep.Resolve([]dep.Package{depPackageFor(rec.Name, rec.Source)})
+		if err != nil {
+			continue
+		}
+		for _, group := range [][]dep.Package{order.Repo, order.RepoMake, order.Community, order.Make} {
+			for _, p := range group {
+				needed[p.Name] = true
+			}
+		}
+	}
+
+	var orphans []Record
+	for _, rec := range d.Packages {
+		if rec.Reason == Dependency && rec.Source == "APT" && !needed[rec.Name] {
+			orphans = append(orphans, rec)
+		}
+	}
+	return orphans, nil
+}
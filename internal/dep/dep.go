@@ -0,0 +1,220 @@
+// Package dep resolves the transitive dependency graph across hpm's
+// backends and orders the result into a DepOrder so a multi-package
+// transaction installs prerequisites before the packages that need them.
+//
+// The category names mirror the depOrder/depCatagories pattern from AUR
+// helpers: Repo is anything a backend already knows how to fetch (apt,
+// snap, flatpak), Community is a community `.hacker` script, and
+// RepoMake/Make are their build-time-only counterparts.
+package dep
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Source categorizes a dependency by where it comes from and whether it is
+// only needed to build/install something else.
+type Source string
+
+const (
+	Repo      Source = "repo"
+	RepoMake  Source = "repo-make"
+	Community Source = "community"
+	Make      Source = "make"
+)
+
+// Package is the minimal identity dep needs to resolve and order a
+// dependency graph. It is intentionally separate from the TUI's Package
+// type to avoid an import cycle; callers convert at the boundary.
+//
+// Backend is the real backend name (e.g. "APT", "SNAP", "FLATPAK") that
+// should install this package, kept distinct from Source: Source is only
+// the Repo/RepoMake/Community/Make bucket used to order the transaction,
+// and collapsing snap/flatpak into that bucket would otherwise lose which
+// backend actually owns the package. An empty Backend means "apt", which
+// is always true for dependencies discovered by walking apt's own graph.
+type Package struct {
+	Name    string
+	Source  Source
+	Backend string
+}
+
+// backendIsApt reports whether p should be queried/installed through apt,
+// which is the default for every dependency discovered via aptDepends or
+// communityDepends rather than named explicitly by a caller.
+func (p Package) backendIsApt() bool {
+	return p.Backend == "" || strings.EqualFold(p.Backend, "APT")
+}
+
+// DepOrder is the topologically-ordered result of a resolution: every
+// package in Repo/RepoMake appears before any Community/Make package that
+// depends on it, so installing bucket-by-bucket in this order (RepoMake,
+// Repo, Make, Community) satisfies every dependency.
+type DepOrder struct {
+	Repo      []Package
+	RepoMake  []Package
+	Community []Package
+	Make      []Package
+	Missing   []string
+}
+
+// communityScriptDir must match tmpDirBase in community/source-code/main.go:
+// that's where a cloned community package's manifest lives once fetched.
+const communityScriptDir = "/tmp/community-packages"
+
+// Resolve walks the transitive dependency graph of targets and returns it
+// ordered so each target's prerequisites precede the target itself. The
+// dependencies of an apt-backed Repo target come from apt-cache's already
+// flattened output and stay in that output's order; they are not sorted
+// topologically among themselves. It detects
+// cycles and records packages whose dependencies could not be determined
+// in DepOrder.Missing instead of failing outright.
+func Resolve(targets []Package) (*DepOrder, error) {
+	r := &resolver{processed: map[string]bool{}, visiting: map[string]bool{}}
+	for _, t := range targets {
+		if r.processed[t.Name] {
+			continue
+		}
+		if err := r.walk(t); err != nil {
+			return nil, err
+		}
+	}
+	return &r.order, nil
+}
+
+type resolver struct {
+	order     DepOrder
+	processed map[string]bool
+	visiting  map[string]bool
+}
+
+func (r *resolver) walk(pkg Package) error {
+	if r.visiting[pkg.Name] {
+		// Back-edge: pkg is already being resolved higher up this same
+		// walk, so whatever needs it there will classify it once that
+		// call unwinds. Treat it as satisfied instead of failing the
+		// whole transaction — cycles are routine in real apt graphs
+		// (multiarch/libc chains and the like).
+		return nil
+	}
+	r.visiting[pkg.Name] = true
+	defer delete(r.visiting, pkg.Name)
+
+	deps, err := dependenciesFor(pkg)
+	if err != nil {
+		r.order.Missing = append(r.order.Missing, pkg.Name)
+		r.processed[pkg.Name] = true
+		return nil
+	}
+
+	// aptDepends already ran --recurse and handed back pkg's full
+	// transitive closure, so each dep here is already fully resolved;
+	// classify it directly instead of re-running --recurse on every node
+	// of a graph apt-cache already flattened once.
+	flattened := pkg.Source == Repo && pkg.backendIsApt()
+	for _, d := range deps {
+		if r.processed[d.Name] {
+			continue
+		}
+		if flattened {
+			r.classify(d)
+			r.processed[d.Name] = true
+			continue
+		}
+		if err := r.walk(d); err != nil {
+			return err
+		}
+	}
+
+	r.classify(pkg)
+	r.processed[pkg.Name] = true
+	return nil
+}
+
+func (r *resolver) classify(pkg Package) {
+	switch pkg.Source {
+	case Repo:
+		r.order.Repo = append(r.order.Repo, pkg)
+	case RepoMake:
+		r.order.RepoMake = append(r.order.RepoMake, pkg)
+	case Community:
+		r.order.Community = append(r.order.Community, pkg)
+	case Make:
+		r.order.Make = append(r.order.Make, pkg)
+	}
+}
+
+func dependenciesFor(pkg Package) ([]Package, error) {
+	switch {
+	case pkg.Source == Repo && pkg.backendIsApt():
+		return aptDepends(pkg.Name)
+	case pkg.Source == Community:
+		return communityDepends(pkg.Name)
+	default:
+		// A Repo package backed by snap/flatpak, or a RepoMake/Make
+		// package: hpm has no dependency graph for those backends, so
+		// it's classified as-is with no further walking.
+		return nil, nil
+	}
+}
+
+// aptDepends shells out to apt-cache depends --recurse, which already
+// flattens the transitive closure, and pulls every "Depends:" line out of
+// it. no-recommends/no-suggests keeps optional deps out of the graph.
+func aptDepends(name string) ([]Package, error) {
+	out, err := exec.Command("apt-cache", "depends", "--recurse", "--no-recommends", "--no-suggests", name).Output()
+	if err != nil {
+		return nil, fmt.Errorf("apt-cache depends %s: %w", name, err)
+	}
+
+	seen := map[string]bool{name: true}
+	var deps []Package
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "Depends:") {
+			continue
+		}
+		depName := strings.TrimSpace(strings.TrimPrefix(line, "Depends:"))
+		depName = strings.Trim(depName, "<>")
+		if depName == "" || seen[depName] {
+			continue
+		}
+		seen[depName] = true
+		deps = append(deps, Package{Name: depName, Source: Repo, Backend: "APT"})
+	}
+	return deps, nil
+}
+
+// communityDepends reads the "# depends:" and "# makedepends:" header
+// comments from a cloned community package's install.hacker script, the
+// same convention PKGBUILDs use for depends/makedepends.
+func communityDepends(name string) ([]Package, error) {
+	script := filepath.Join(communityScriptDir, name, "install.hacker")
+	f, err := os.Open(script)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest for %s: %w", name, err)
+	}
+	defer f.Close()
+
+	var deps []Package
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "# depends:"):
+			for _, n := range strings.Fields(strings.TrimPrefix(line, "# depends:")) {
+				deps = append(deps, Package{Name: n, Source: Repo, Backend: "APT"})
+			}
+		case strings.HasPrefix(line, "# makedepends:"):
+			for _, n := range strings.Fields(strings.TrimPrefix(line, "# makedepends:")) {
+				deps = append(deps, Package{Name: n, Source: RepoMake, Backend: "APT"})
+			}
+		}
+	}
+	return deps, scanner.Err()
+}
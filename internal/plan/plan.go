@@ -0,0 +1,179 @@
+// Package plan builds TransactionPlans by running each backend's simulate
+// (dry-run) mode and parsing the result, so the TUI can show the user what
+// would happen before it actually mutates the system.
+package plan
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Change describes a single package affected by a transaction.
+type Change struct {
+	Name           string
+	CurrentVersion string
+	NewVersion     string
+	Repo           string
+	Arch           string
+}
+
+// TransactionPlan is the aggregated result of simulating an install or
+// remove for a given backend.
+type TransactionPlan struct {
+	Source        string
+	Installing    []Change
+	Upgrading     []Change
+	Removing      []Change
+	DownloadSize  string
+	InstalledSize string
+}
+
+var (
+	instRe = regexp.MustCompile(`^Inst (\S+) (?:\[(\S+)\] )?\((\S+) ([\S/]+)(?: \[(\S+)\])?\)`)
+	remvRe = regexp.MustCompile(`^Remv (\S+) \[(\S+)\]`)
+	getRe  = regexp.MustCompile(`Need to get ([\d.,]+ ?[kMG]?B) of archives`)
+	sizeRe = regexp.MustCompile(`After this operation, ([\d.,]+ ?[kMG]?B) (?:of additional disk space will be used|disk space will be freed)`)
+)
+
+// parseAptSimulate mirrors the apt-fronted Python parser: it walks the
+// output of `apt-get -s` and pulls out Inst/Remv lines plus the archive and
+// disk-space summary lines.
+func parseAptSimulate(output string) *TransactionPlan {
+	p := &TransactionPlan{Source: "APT", DownloadSize: "0", InstalledSize: "0"}
+
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(line, "Inst "):
+			m := instRe.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			name, curVer, newVer, repo, arch := m[1], m[2], m[3], m[4], m[5]
+			if arch == "" {
+				arch = "unknown"
+			}
+			c := Change{Name: name, CurrentVersion: curVer, NewVersion: newVer, Repo: repo, Arch: arch}
+			if curVer != "" {
+				p.Upgrading = append(p.Upgrading, c)
+			} else {
+				p.Installing = append(p.Installing, c)
+			}
+		case strings.HasPrefix(line, "Remv "):
+			m := remvRe.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			p.Removing = append(p.Removing, Change{Name: m[1], CurrentVersion: m[2], Repo: "N/A", Arch: "unknown"})
+		case strings.Contains(line, "Need to get"):
+			if m := getRe.FindStringSubmatch(line); m != nil {
+				p.DownloadSize = m[1]
+			}
+		case strings.Contains(line, "After this operation"):
+			if m := sizeRe.FindStringSubmatch(line); m != nil {
+				p.InstalledSize = m[1]
+			}
+		}
+	}
+
+	return p
+}
+
+// SimulateApt runs `apt-get -s install|remove` for the given packages and
+// parses the output into a TransactionPlan. apt-get exits non-zero for some
+// simulate cases (e.g. removing a package that isn't installed), so the
+// output is parsed even when the command errors.
+func SimulateApt(names []string, remove bool) (*TransactionPlan, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no packages given")
+	}
+
+	verb := "install"
+	if remove {
+		verb = "remove"
+	}
+
+	args := append([]string{"-s", verb}, names...)
+	out, err := exec.Command("apt-get", args...).CombinedOutput()
+	if err != nil && len(out) == 0 {
+		return nil, fmt.Errorf("apt-get -s %s: %w", verb, err)
+	}
+
+	return parseAptSimulate(string(out)), nil
+}
+
+// SimulateSnap best-effort simulates a snap transaction. Snap has no true
+// dry-run for install, so this only reports the version listed for the
+// latest/stable channel in `snap info`; sizes are not reported.
+func SimulateSnap(name string, remove bool) (*TransactionPlan, error) {
+	p := &TransactionPlan{Source: "SNAP", DownloadSize: "unknown", InstalledSize: "unknown"}
+
+	if remove {
+		p.Removing = append(p.Removing, Change{Name: name, Repo: "snap", Arch: "unknown"})
+		return p, nil
+	}
+
+	out, err := exec.Command("snap", "info", name).Output()
+	if err != nil {
+		return nil, fmt.Errorf("snap info %s: %w", name, err)
+	}
+
+	version := "unknown"
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "channels:") {
+			continue
+		}
+		if strings.HasPrefix(line, "latest/stable:") {
+			fields := strings.Fields(strings.TrimPrefix(line, "latest/stable:"))
+			if len(fields) > 0 {
+				version = fields[0]
+			}
+			break
+		}
+	}
+
+	p.Installing = append(p.Installing, Change{Name: name, NewVersion: version, Repo: "snap", Arch: "unknown"})
+	return p, nil
+}
+
+// SimulateCommunity builds a best-effort plan for a community `.hacker`
+// package. The standalone `community` tool has no dry-run mode for its
+// install/remove scripts, so unlike the other Simulate* functions this
+// can't preview what the script will actually do; it just confirms which
+// package and action the user is about to run before CommunityBackend
+// executes it.
+func SimulateCommunity(name string, remove bool) *TransactionPlan {
+	p := &TransactionPlan{Source: "COMMUNITY", DownloadSize: "unknown", InstalledSize: "unknown"}
+
+	c := Change{Name: name, Repo: "community", Arch: "unknown"}
+	if remove {
+		p.Removing = append(p.Removing, c)
+	} else {
+		p.Installing = append(p.Installing, c)
+	}
+	return p
+}
+
+// SimulateFlatpak simulates a flatpak transaction via `flatpak install
+// --no-deploy`, which downloads but does not deploy the ref. The command's
+// output is not parsed: the plan lists only the requested ref itself, with
+// no version, related refs or sizes, and the command failing counts as an
+// error only when it printed nothing.
+func SimulateFlatpak(name string, remove bool) (*TransactionPlan, error) {
+	p := &TransactionPlan{Source: "FLATPAK", DownloadSize: "unknown", InstalledSize: "unknown"}
+
+	if remove {
+		p.Removing = append(p.Removing, Change{Name: name, Repo: "flatpak", Arch: "unknown"})
+		return p, nil
+	}
+
+	out, err := exec.Command("flatpak", "install", "--no-deploy", "--assumeyes", name).CombinedOutput()
+	if err != nil && len(out) == 0 {
+		return nil, fmt.Errorf("flatpak install --no-deploy %s: %w", name, err)
+	}
+
+	p.Installing = append(p.Installing, Change{Name: name, Repo: "flatpak", Arch: "unknown"})
+	return p, nil
+}
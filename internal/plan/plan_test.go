@@ -0,0 +1,75 @@
+package plan
+
+import "testing"
+
+func TestParseAptSimulateInstall(t *testing.T) {
+	output := `Inst curl (7.81.0-1ubuntu1.15 Ubuntu:22.04/jammy-updates [amd64])
+Need to get 220 kB of archives.
+After this operation, 615 kB of additional disk space will be used.`
+
+	p := parseAptSimulate(output)
+
+	if len(p.Installing) != 1 {
+		t.Fatalf("Installing = %v, want 1 entry", p.Installing)
+	}
+	got := p.Installing[0]
+	want := Change{Name: "curl", NewVersion: "7.81.0-1ubuntu1.15", Repo: "Ubuntu:22.04/jammy-updates", Arch: "amd64"}
+	if got != want {
+		t.Errorf("Installing[0] = %+v, want %+v", got, want)
+	}
+	if p.DownloadSize != "220 kB" {
+		t.Errorf("DownloadSize = %q, want %q", p.DownloadSize, "220 kB")
+	}
+	if p.InstalledSize != "615 kB" {
+		t.Errorf("InstalledSize = %q, want %q", p.InstalledSize, "615 kB")
+	}
+}
+
+func TestParseAptSimulateUpgradeNoArch(t *testing.T) {
+	output := `Inst curl [7.68.0-1ubuntu2.18] (7.81.0-1ubuntu1.15 Ubuntu:22.04/jammy-updates)`
+
+	p := parseAptSimulate(output)
+
+	if len(p.Upgrading) != 1 {
+		t.Fatalf("Upgrading = %v, want 1 entry", p.Upgrading)
+	}
+	got := p.Upgrading[0]
+	want := Change{Name: "curl", CurrentVersion: "7.68.0-1ubuntu2.18", NewVersion: "7.81.0-1ubuntu1.15", Repo: "Ubuntu:22.04/jammy-updates", Arch: "unknown"}
+	if got != want {
+		t.Errorf("Upgrading[0] = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseAptSimulateRemove(t *testing.T) {
+	output := `Remv curl [7.81.0-1ubuntu1.15]
+After this operation, 615 kB disk space will be freed.`
+
+	p := parseAptSimulate(output)
+
+	if len(p.Removing) != 1 {
+		t.Fatalf("Removing = %v, want 1 entry", p.Removing)
+	}
+	got := p.Removing[0]
+	want := Change{Name: "curl", CurrentVersion: "7.81.0-1ubuntu1.15", Repo: "N/A", Arch: "unknown"}
+	if got != want {
+		t.Errorf("Removing[0] = %+v, want %+v", got, want)
+	}
+	if p.InstalledSize != "615 kB" {
+		t.Errorf("InstalledSize = %q, want %q", p.InstalledSize, "615 kB")
+	}
+}
+
+func TestParseAptSimulateIgnoresMalformedLines(t *testing.T) {
+	output := `Inst
+Remv
+some unrelated line`
+
+	p := parseAptSimulate(output)
+
+	if len(p.Installing) != 0 || len(p.Upgrading) != 0 || len(p.Removing) != 0 {
+		t.Fatalf("expected no changes parsed from malformed lines, got %+v", p)
+	}
+	if p.DownloadSize != "0" || p.InstalledSize != "0" {
+		t.Errorf("expected default sizes, got download=%q installed=%q", p.DownloadSize, p.InstalledSize)
+	}
+}
@@ -0,0 +1,60 @@
+package repoindex
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// CheckoutPinned checks out entry's pinned commit inside an already-cloned
+// repo at dir and verifies the resulting tree hash against entry.SHA256. A
+// package published without a pin is not checked here; callers that want
+// to require pinning must check entry.Commit/SHA256 themselves before
+// calling.
+func CheckoutPinned(dir string, entry Entry) error {
+	if entry.Commit != "" {
+		cmd := exec.Command("git", "-C", dir, "checkout", "--quiet", entry.Commit)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("checking out pinned commit %s: %w\n%s", entry.Commit, err, out)
+		}
+	}
+
+	if entry.SHA256 != "" {
+		got, err := TreeHash(dir)
+		if err != nil {
+			return fmt.Errorf("hashing tree at %s: %w", dir, err)
+		}
+		if got != strings.ToLower(entry.SHA256) {
+			return fmt.Errorf("tree hash mismatch for %s: expected %s, got %s", dir, entry.SHA256, got)
+		}
+	}
+
+	return nil
+}
+
+// TreeHash returns the sha256 of `git archive HEAD` for the repo at dir,
+// giving a single hex digest over the checked-out tree's contents that a
+// repo.hacker entry can pin against.
+func TreeHash(dir string) (string, error) {
+	cmd := exec.Command("git", "-C", dir, "archive", "HEAD")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, stdout); err != nil {
+		return "", err
+	}
+	if err := cmd.Wait(); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
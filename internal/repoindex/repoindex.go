@@ -0,0 +1,94 @@
+// Package repoindex parses and verifies the community repo index
+// (repo.hacker) shared by the `community` CLI and the TUI's
+// CommunityBackend, so both consumers agree on one entry format and one
+// signature-verification path instead of each re-implementing the parser.
+package repoindex
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Entry is one `name -> url [sha256=... commit=...]` line from repo.hacker.
+// SHA256 and Commit are optional; a package published before pinning was
+// required has neither set, and callers that require a pin should reject
+// it explicitly rather than treating an empty pin as a match.
+type Entry struct {
+	Name   string
+	URL    string
+	SHA256 string
+	Commit string
+}
+
+// Parse reads a repo.hacker index and returns its entries keyed by name.
+// The format is a bracketed array of `name -> url` lines, optionally
+// followed by a `[sha256=<hex> commit=<sha>]` pin, e.g.:
+//
+//	[
+//	  foo -> https://github.com/example/foo [sha256=abcd... commit=deadbeef],
+//	  bar -> https://github.com/example/bar,
+//	]
+func Parse(r io.Reader) (map[string]Entry, error) {
+	entries := make(map[string]Entry)
+	inArray := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "[":
+			inArray = true
+		case line == "]":
+			inArray = false
+		case inArray && strings.Contains(line, "->"):
+			entry, err := parseLine(line)
+			if err != nil {
+				return nil, err
+			}
+			entries[entry.Name] = entry
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func parseLine(line string) (Entry, error) {
+	parts := strings.SplitN(line, "->", 2)
+	if len(parts) != 2 {
+		return Entry{}, fmt.Errorf("malformed repo.hacker line: %q", line)
+	}
+
+	name := strings.TrimSpace(parts[0])
+	rest := strings.TrimSuffix(strings.TrimSpace(parts[1]), ",")
+
+	entry := Entry{Name: name}
+
+	if open := strings.IndexByte(rest, '['); open != -1 {
+		shut := strings.LastIndexByte(rest, ']')
+		if shut == -1 || shut < open {
+			return Entry{}, fmt.Errorf("unterminated pin in repo.hacker line: %q", line)
+		}
+		entry.URL = strings.TrimSpace(rest[:open])
+		for _, field := range strings.Fields(rest[open+1 : shut]) {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			switch kv[0] {
+			case "sha256":
+				entry.SHA256 = kv[1]
+			case "commit":
+				entry.Commit = kv[1]
+			}
+		}
+	} else {
+		entry.URL = rest
+	}
+
+	return entry, nil
+}
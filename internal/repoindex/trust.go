@@ -0,0 +1,102 @@
+package repoindex
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// TrustedKeyDir holds additional maintainer keys an operator has chosen to
+// trust, on top of the ones shipped in the binary. Files here are written
+// by `hpm community trust --add` and loaded at every LoadTrustStore call.
+const TrustedKeyDir = "/etc/hpm/trusted.d"
+
+//go:embed keys/*.asc
+var embeddedKeys embed.FS
+
+// ErrUntrustedSignature means the index's detached signature did not
+// verify against any key in the trust store.
+var ErrUntrustedSignature = errors.New("repoindex: signature not signed by a trusted key")
+
+// LoadTrustStore reads every embedded maintainer key plus any *.asc file
+// under TrustedKeyDir into a single keyring.
+func LoadTrustStore() (openpgp.EntityList, error) {
+	var keyring openpgp.EntityList
+
+	embeddedEntries, err := fs.Glob(embeddedKeys, "keys/*.asc")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range embeddedEntries {
+		f, err := embeddedKeys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		entities, err := openpgp.ReadArmoredKeyRing(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parsing embedded key %s: %w", name, err)
+		}
+		keyring = append(keyring, entities...)
+	}
+
+	localEntries, err := filepath.Glob(filepath.Join(TrustedKeyDir, "*.asc"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range localEntries {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		entities, err := openpgp.ReadArmoredKeyRing(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parsing trusted key %s: %w", path, err)
+		}
+		keyring = append(keyring, entities...)
+	}
+
+	return keyring, nil
+}
+
+// VerifyDetached checks signed against an armored detached signature,
+// requiring the signer to be in keyring. Every verification failure (a key
+// the keyring doesn't have, a malformed signature, or a digest mismatch) is
+// returned wrapped in ErrUntrustedSignature.
+func VerifyDetached(signed, signature []byte, keyring openpgp.EntityList) error {
+	_, err := openpgp.CheckArmoredDetachedSignature(
+		keyring,
+		bytes.NewReader(signed),
+		bytes.NewReader(signature),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUntrustedSignature, err)
+	}
+	return nil
+}
+
+// AddTrustedKey copies an armored public key file into TrustedKeyDir so
+// future LoadTrustStore calls include it. It is validated as a parseable
+// keyring before being written.
+func AddTrustedKey(keyfilePath string) error {
+	data, err := os.ReadFile(keyfilePath)
+	if err != nil {
+		return err
+	}
+	if _, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("%s is not a valid armored public key: %w", keyfilePath, err)
+	}
+
+	if err := os.MkdirAll(TrustedKeyDir, 0755); err != nil {
+		return err
+	}
+	dest := filepath.Join(TrustedKeyDir, filepath.Base(keyfilePath))
+	return os.WriteFile(dest, data, 0644)
+}
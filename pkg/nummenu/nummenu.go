@@ -0,0 +1,107 @@
+// Package nummenu parses yay-style numbered selection input, e.g.
+// "1 2 3", "1-5", or "1-5 ^3", into an explicit set of included and
+// excluded indices. It has no dependency on how the menu itself was
+// rendered, so both the TUI's batch search mode and any future caller can
+// share one parser instead of re-implementing range/exclusion handling.
+package nummenu
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseError reports the specific token that failed to parse and why,
+// rather than a generic "malformed input" so a CLI can echo back exactly
+// what was wrong.
+type ParseError struct {
+	Token  string
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid selection %q: %s", e.Token, e.Reason)
+}
+
+// Parse splits input on spaces and/or commas into tokens, each either a
+// single 1-based index, an inclusive range ("1-5"), or either of those
+// prefixed with "^" to exclude it. The result is include minus exclude:
+// an index named by both an include and an exclude token ends up
+// excluded. Indices outside [1, max], malformed ranges (start > end), and
+// unparsable tokens are rejected with a *ParseError instead of being
+// silently dropped.
+func Parse(input string, max int) (include, exclude []int, err error) {
+	incSet := map[int]bool{}
+	excSet := map[int]bool{}
+
+	for _, tok := range fields(input) {
+		negate := strings.HasPrefix(tok, "^")
+		tok = strings.TrimPrefix(tok, "^")
+
+		lo, hi, err := parseRange(tok, max)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for i := lo; i <= hi; i++ {
+			if negate {
+				excSet[i] = true
+			} else {
+				incSet[i] = true
+			}
+		}
+	}
+
+	for i := range excSet {
+		delete(incSet, i)
+	}
+
+	return sortedKeys(incSet), sortedKeys(excSet), nil
+}
+
+func fields(input string) []string {
+	return strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
+
+func parseRange(tok string, max int) (lo, hi int, err error) {
+	if tok == "" {
+		return 0, 0, &ParseError{Token: tok, Reason: "empty selection"}
+	}
+
+	if i := strings.IndexByte(tok, '-'); i > 0 {
+		lo, err = strconv.Atoi(tok[:i])
+		if err != nil {
+			return 0, 0, &ParseError{Token: tok, Reason: "not a number"}
+		}
+		hi, err = strconv.Atoi(tok[i+1:])
+		if err != nil {
+			return 0, 0, &ParseError{Token: tok, Reason: "not a number"}
+		}
+		if lo > hi {
+			return 0, 0, &ParseError{Token: tok, Reason: "range start is greater than its end"}
+		}
+	} else {
+		lo, err = strconv.Atoi(tok)
+		if err != nil {
+			return 0, 0, &ParseError{Token: tok, Reason: "not a number"}
+		}
+		hi = lo
+	}
+
+	if lo < 1 || hi > max {
+		return 0, 0, &ParseError{Token: tok, Reason: fmt.Sprintf("out of range 1-%d", max)}
+	}
+	return lo, hi, nil
+}
+
+func sortedKeys(set map[int]bool) []int {
+	out := make([]int, 0, len(set))
+	for i := range set {
+		out = append(out, i)
+	}
+	sort.Ints(out)
+	return out
+}
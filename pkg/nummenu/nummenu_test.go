@@ -0,0 +1,61 @@
+package nummenu
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		max     int
+		include []int
+		exclude []int
+	}{
+		{"single", "3", 5, []int{3}, []int{}},
+		{"list", "1 2 3", 5, []int{1, 2, 3}, []int{}},
+		{"comma separated", "1,2,3", 5, []int{1, 2, 3}, []int{}},
+		{"range", "1-5", 5, []int{1, 2, 3, 4, 5}, []int{}},
+		{"range with exclusion", "1-5 ^3", 5, []int{1, 2, 4, 5}, []int{3}},
+		{"exclusion only", "^2", 5, []int{}, []int{2}},
+		{"dedup", "1 1 2", 5, []int{1, 2}, []int{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			include, exclude, err := Parse(c.input, c.max)
+			if err != nil {
+				t.Fatalf("Parse(%q, %d) returned error: %v", c.input, c.max, err)
+			}
+			if !reflect.DeepEqual(include, c.include) {
+				t.Errorf("include = %v, want %v", include, c.include)
+			}
+			if !reflect.DeepEqual(exclude, c.exclude) {
+				t.Errorf("exclude = %v, want %v", exclude, c.exclude)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		max   int
+	}{
+		{"out of range high", "6", 5},
+		{"out of range low", "0", 5},
+		{"malformed range", "5-1", 5},
+		{"not a number", "abc", 5},
+		{"exclusion marker with nothing to exclude", "^", 5},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, _, err := Parse(c.input, c.max); err == nil {
+				t.Fatalf("Parse(%q, %d) = nil error, want error", c.input, c.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/HackerOS-Linux-System/Hacker-Package-Manager/internal/db"
+	"github.com/HackerOS-Linux-System/Hacker-Package-Manager/internal/dep"
+	"github.com/HackerOS-Linux-System/Hacker-Package-Manager/pkg/nummenu"
+)
+
+// runBatchSearch implements `hpm -Ss <query>`: a non-interactive yay-style
+// numbered menu over search results, feeding the selection into the same
+// dependency-resolved install path the TUI's 'D' key uses.
+func runBatchSearch(query string) {
+	pkgs, err := searchPackages(query)
+	if err != nil {
+		fmt.Println("Search failed:", err)
+		os.Exit(1)
+	}
+	if len(pkgs) == 0 {
+		fmt.Println("No packages found.")
+		return
+	}
+
+	for i, p := range pkgs {
+		fmt.Printf("%3d  %s (%s) - %s\n", i+1, p.name, p.source, p.desc)
+	}
+
+	fmt.Print("Packages to install (eg: 1 2 3, 1-3 or ^4): ")
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return
+	}
+
+	include, _, err := nummenu.Parse(scanner.Text(), len(pkgs))
+	if err != nil {
+		fmt.Println("Invalid selection:", err)
+		os.Exit(1)
+	}
+	if len(include) == 0 {
+		fmt.Println("Nothing selected.")
+		return
+	}
+
+	targets := make([]Package, 0, len(include))
+	for _, i := range include {
+		targets = append(targets, pkgs[i-1])
+	}
+
+	depTargets := make([]dep.Package, 0, len(targets))
+	for _, p := range targets {
+		depTargets = append(depTargets, toDepPackage(p))
+	}
+	order, err := dep.Resolve(depTargets)
+	if err != nil {
+		fmt.Println("Resolving dependencies failed:", err)
+		os.Exit(1)
+	}
+	if len(order.Missing) > 0 {
+		fmt.Println("Could not resolve:", strings.Join(order.Missing, ", "))
+	}
+
+	installDB, err := db.Load()
+	if err != nil {
+		fmt.Println("warning: could not load package db:", err)
+	}
+
+	lines, err := runInstallOrder(order, targets, installDB)
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	if err != nil {
+		fmt.Println("Install failed:", err)
+		os.Exit(1)
+	}
+}
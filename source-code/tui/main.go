@@ -1,17 +1,23 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 
-	"github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/HackerOS-Linux-System/Hacker-Package-Manager/internal/backend"
+	"github.com/HackerOS-Linux-System/Hacker-Package-Manager/internal/db"
+	"github.com/HackerOS-Linux-System/Hacker-Package-Manager/internal/dep"
+	"github.com/HackerOS-Linux-System/Hacker-Package-Manager/internal/plan"
 )
 
 type Source string
@@ -65,25 +71,104 @@ type removeResult struct {
 	err    error
 }
 
+type pendingPlan struct {
+	plan   *plan.TransactionPlan
+	target Package
+	action string // "install" or "remove"
+}
+
+type planResult struct {
+	plan   *plan.TransactionPlan
+	target Package
+	action string
+	err    error
+}
+
+type depResult struct {
+	order   *dep.DepOrder
+	targets []Package
+	err     error
+}
+
+// backendEventMsg carries one Event off a backend's Install/Remove channel;
+// the handler re-arms waitForEvent until the channel reports Done.
+type backendEventMsg struct {
+	ch     <-chan backend.Event
+	ev     backend.Event
+	ok     bool
+	action string
+	source Source
+}
+
+func waitForEvent(ch <-chan backend.Event, action string, source Source) tea.Cmd {
+	return func() tea.Msg {
+		ev, ok := <-ch
+		return backendEventMsg{ch: ch, ev: ev, ok: ok, action: action, source: source}
+	}
+}
+
+// sourceForKey finds the enabled backend whose name starts with key, so the
+// source filter (a/s/f/c/...) reflects whatever backends are registered
+// instead of a fixed apt/snap/flatpak switch.
+func sourceForKey(key string) (Source, bool) {
+	for _, b := range backend.Enabled() {
+		if strings.HasPrefix(strings.ToLower(b.Name()), key) {
+			return Source(b.Name()), true
+		}
+	}
+	return "", false
+}
+
+func pkgKey(p Package) string {
+	return string(p.source) + ":" + p.name
+}
+
+// toDepPackage maps a searchable Package to the dep package's identity:
+// apt, snap and flatpak all count as the Repo category for ordering
+// purposes, but Backend still carries which of them actually owns the
+// package so resolution can dispatch to the right one later. Community
+// `.hacker` scripts are their own Community category and have no backend
+// to dispatch to directly.
+func toDepPackage(p Package) dep.Package {
+	if p.source == Source("COMMUNITY") {
+		return dep.Package{Name: p.name, Source: dep.Community}
+	}
+	return dep.Package{Name: p.name, Source: dep.Repo, Backend: string(p.source)}
+}
+
 type model struct {
-	textInput      textinput.Model
-	pkgList        list.Model
-	mode           InputMode
-	selectedSource Source
-	message        string
-	dotCount       int
-	isSearching    bool
-	isInstalling   bool
-	isRemoving     bool
-	packages       []Package
-	quitting       bool
+	textInput         textinput.Model
+	pkgList           list.Model
+	mode              InputMode
+	selectedSource    Source
+	message           string
+	dotCount          int
+	isSearching       bool
+	isInstalling      bool
+	isRemoving        bool
+	isPlanning        bool
+	isResolving       bool
+	pendingPlan       *pendingPlan
+	pendingDeps       *dep.DepOrder
+	pendingDepTargets []Package
+	pendingOrphans    []db.Record
+	selected          map[string]Package
+	packages          []Package
+	progressBar       progress.Model
+	installDB         *db.DB
+	quitting          bool
 }
 
 var (
-	inputStyle   = lipgloss.NewStyle().Border(lipgloss.NormalBorder()).Padding(0, 1)
-	listStyle    = lipgloss.NewStyle().Margin(1, 0)
-	helpStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
-	messageStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	inputStyle    = lipgloss.NewStyle().Border(lipgloss.NormalBorder()).Padding(0, 1)
+	listStyle     = lipgloss.NewStyle().Margin(1, 0)
+	helpStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	messageStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	planBoxStyle  = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1).Margin(1, 0)
+	installStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	upgradeStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	removeStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	planSizeStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("14"))
 )
 
 func New(initialQuery string) model {
@@ -97,6 +182,11 @@ func New(initialQuery string) model {
 	l.SetShowPagination(false)
 	l.SetShowTitle(true)
 
+	installDB, err := db.Load()
+	if err != nil {
+		installDB = &db.DB{Packages: map[string]db.Record{}}
+	}
+
 	m := model{
 		textInput:      ti,
 		pkgList:        l,
@@ -104,6 +194,9 @@ func New(initialQuery string) model {
 		selectedSource: All,
 		message:        "",
 		packages:       []Package{},
+		selected:       map[string]Package{},
+		progressBar:    progress.New(progress.WithDefaultGradient()),
+		installDB:      installDB,
 	}
 
 	if initialQuery != "" {
@@ -125,136 +218,299 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
-		case tea.WindowSizeMsg:
-			m.textInput.Width = msg.Width - 4
-			m.pkgList.SetWidth(msg.Width)
-			m.pkgList.SetHeight(msg.Height - 8) // Approximate for input and help
+	case tea.WindowSizeMsg:
+		m.textInput.Width = msg.Width - 4
+		m.pkgList.SetWidth(msg.Width)
+		m.pkgList.SetHeight(msg.Height - 8) // Approximate for input and help
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.isSearching || m.isInstalling || m.isRemoving || m.isPlanning || m.isResolving {
+			if msg.String() == "q" || msg.String() == "ctrl+c" {
+				m.quitting = true
+				return m, tea.Quit
+			}
 			return m, nil
+		}
 
-		case tea.KeyMsg:
-			if m.isSearching || m.isInstalling || m.isRemoving {
-				if msg.String() == "q" || msg.String() == "ctrl+c" {
-					m.quitting = true
-					return m, tea.Quit
+		if m.pendingPlan != nil {
+			switch msg.String() {
+			case "enter":
+				pp := m.pendingPlan
+				m.pendingPlan = nil
+				if pp.action == "remove" {
+					m.message = "Removing..."
+					m.isRemoving = true
+					return m, startTransaction(pp.target, "remove")
 				}
+				m.message = "Installing..."
+				m.isInstalling = true
+				return m, startTransaction(pp.target, "install")
+			case "esc", "q", "ctrl+c":
+				m.pendingPlan = nil
+				m.message = "Plan cancelled."
+				return m, nil
+			}
+			return m, nil
+		}
+
+		if m.pendingDeps != nil {
+			switch msg.String() {
+			case "enter":
+				order := m.pendingDeps
+				targets := m.pendingDepTargets
+				m.pendingDeps = nil
+				m.pendingDepTargets = nil
+				m.message = "Installing in resolved order..."
+				m.isInstalling = true
+				return m, installOrderCmd(order, targets, m.installDB)
+			case "esc", "q", "ctrl+c":
+				m.pendingDeps = nil
+				m.pendingDepTargets = nil
+				m.message = "Dependency resolution cancelled."
+				return m, nil
+			}
+			return m, nil
+		}
+
+		if m.pendingOrphans != nil {
+			switch msg.String() {
+			case "enter":
+				orphans := m.pendingOrphans
+				m.pendingOrphans = nil
+				m.message = "Removing orphaned packages..."
+				m.isRemoving = true
+				return m, removeOrphansCmd(orphans, m.installDB)
+			case "esc", "q", "ctrl+c":
+				m.pendingOrphans = nil
+				m.message = "Orphan cleanup cancelled."
+				return m, nil
+			}
+			return m, nil
+		}
+
+		switch m.mode {
+		case Editing:
+			switch msg.String() {
+			case "esc":
+				m.mode = Normal
+				m.textInput.Blur()
+				return m, nil
+			case "enter":
+				m.mode = Normal
+				m.textInput.Blur()
 				return m, nil
 			}
+			m.textInput, cmd = m.textInput.Update(msg)
+			cmds = append(cmds, cmd)
+			return m, tea.Batch(cmds...)
 
-			switch m.mode {
-				case Editing:
-					switch msg.String() {
-						case "esc":
-							m.mode = Normal
-							m.textInput.Blur()
-							return m, nil
-						case "enter":
-							m.mode = Normal
-							m.textInput.Blur()
-							return m, nil
+		case Normal:
+			switch msg.String() {
+			case "q", "ctrl+c":
+				m.quitting = true
+				return m, tea.Quit
+			case "e":
+				m.mode = Editing
+				m.textInput.Focus()
+				return m, textinput.Blink
+			case "enter":
+				query := m.textInput.Value()
+				if query == "" {
+					m.message = "Enter a search query."
+					return m, nil
+				}
+				m.message = "Searching"
+				m.dotCount = 0
+				m.isSearching = true
+				return m, tea.Batch(searchCmd(query), tick())
+			case "i":
+				if item, ok := m.pkgList.SelectedItem().(Package); ok {
+					m.message = "Building plan..."
+					m.isPlanning = true
+					return m, planCmd(item, "install")
+				}
+			case "r":
+				if item, ok := m.pkgList.SelectedItem().(Package); ok {
+					m.message = "Building plan..."
+					m.isPlanning = true
+					return m, planCmd(item, "remove")
+				}
+			case " ":
+				if item, ok := m.pkgList.SelectedItem().(Package); ok {
+					key := pkgKey(item)
+					if _, ok := m.selected[key]; ok {
+						delete(m.selected, key)
+					} else {
+						m.selected[key] = item
 					}
-					m.textInput, cmd = m.textInput.Update(msg)
-					cmds = append(cmds, cmd)
-					return m, tea.Batch(cmds...)
-
-						case Normal:
-							switch msg.String() {
-								case "q", "ctrl+c":
-									m.quitting = true
-									return m, tea.Quit
-								case "e":
-									m.mode = Editing
-									m.textInput.Focus()
-									return m, textinput.Blink
-								case "enter":
-									query := m.textInput.Value()
-									if query == "" {
-										m.message = "Enter a search query."
-										return m, nil
-									}
-									m.message = "Searching"
-									m.dotCount = 0
-									m.isSearching = true
-									return m, tea.Batch(searchCmd(query), tick())
-								case "i":
-									if item, ok := m.pkgList.SelectedItem().(Package); ok {
-										m.message = "Installing..."
-										m.isInstalling = true
-										return m, installCmd(item)
-									}
-								case "r":
-									if item, ok := m.pkgList.SelectedItem().(Package); ok {
-										m.message = "Removing..."
-										m.isRemoving = true
-										return m, removeCmd(item)
-									}
-								case "a":
-									m.selectedSource = Apt
-									m.updateList()
-									return m, nil
-								case "s":
-									m.selectedSource = Snap
-									m.updateList()
-									return m, nil
-								case "f":
-									m.selectedSource = Flatpak
-									m.updateList()
-									return m, nil
-								case "l":
-									m.selectedSource = All
-									m.updateList()
-									return m, nil
-								default:
-									m.pkgList, cmd = m.pkgList.Update(msg)
-									cmds = append(cmds, cmd)
-									return m, tea.Batch(cmds...)
-							}
+					m.message = fmt.Sprintf("%d package(s) selected.", len(m.selected))
+				}
+				return m, nil
+			case "D":
+				targets := make([]Package, 0, len(m.selected))
+				for _, p := range m.selected {
+					targets = append(targets, p)
+				}
+				if len(targets) == 0 {
+					if item, ok := m.pkgList.SelectedItem().(Package); ok {
+						targets = append(targets, item)
+					}
+				}
+				if len(targets) == 0 {
+					m.message = "No packages selected."
+					return m, nil
+				}
+				m.message = "Resolving dependencies..."
+				m.isResolving = true
+				return m, resolveCmd(targets)
+			case "o":
+				orphans, err := m.installDB.Orphans()
+				if err != nil {
+					m.message = fmt.Sprintf("Orphan scan failed: %v", err)
+					return m, nil
+				}
+				if len(orphans) == 0 {
+					m.message = "No orphaned packages."
+					return m, nil
+				}
+				m.pendingOrphans = orphans
+				return m, nil
+			case "l":
+				m.selectedSource = All
+				m.updateList()
+				return m, nil
+			default:
+				if src, ok := sourceForKey(msg.String()); ok {
+					m.selectedSource = src
+					m.updateList()
+					return m, nil
+				}
+				m.pkgList, cmd = m.pkgList.Update(msg)
+				cmds = append(cmds, cmd)
+				return m, tea.Batch(cmds...)
+			}
+		}
+
+	case tickMsg:
+		if m.isSearching {
+			m.dotCount = (m.dotCount + 1) % 4
+			m.message = "Searching" + strings.Repeat(".", m.dotCount)
+			return m, tick()
+		}
+		return m, nil
+
+	case searchResult:
+		m.isSearching = false
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Search failed: %v", msg.err)
+		} else {
+			m.packages = msg.pkgs
+			if len(m.packages) == 0 {
+				m.message = "No packages found."
+			} else {
+				m.message = ""
 			}
+			m.updateList()
+		}
+		return m, nil
+
+	case installResult:
+		m.isInstalling = false
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Install failed: %v", msg.err)
+		} else {
+			m.message = msg.output
+		}
+		return m, nil
+
+	case removeResult:
+		m.isRemoving = false
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Remove failed: %v", msg.err)
+		} else {
+			m.message = msg.output
+		}
+		return m, nil
+
+	case planResult:
+		m.isPlanning = false
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Plan failed: %v", msg.err)
+		} else {
+			m.pendingPlan = &pendingPlan{plan: msg.plan, target: msg.target, action: msg.action}
+			m.message = ""
+		}
+		return m, nil
 
-								case tickMsg:
-									if m.isSearching {
-										m.dotCount = (m.dotCount + 1) % 4
-										m.message = "Searching" + strings.Repeat(".", m.dotCount)
-										return m, tick()
-									}
-									return m, nil
-
-								case searchResult:
-									m.isSearching = false
-									if msg.err != nil {
-										m.message = fmt.Sprintf("Search failed: %v", msg.err)
-									} else {
-										m.packages = msg.pkgs
-										if len(m.packages) == 0 {
-											m.message = "No packages found."
-										} else {
-											m.message = ""
-										}
-										m.updateList()
-									}
-									return m, nil
-
-								case installResult:
-									m.isInstalling = false
-									if msg.err != nil {
-										m.message = fmt.Sprintf("Install failed: %v", msg.err)
-									} else {
-										m.message = msg.output
-									}
-									return m, nil
-
-								case removeResult:
-									m.isRemoving = false
-									if msg.err != nil {
-										m.message = fmt.Sprintf("Remove failed: %v", msg.err)
-									} else {
-										m.message = msg.output
-									}
-									return m, nil
+	case depResult:
+		m.isResolving = false
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Dependency resolution failed: %v", msg.err)
+		} else {
+			m.pendingDeps = msg.order
+			m.pendingDepTargets = msg.targets
+			m.selected = map[string]Package{}
+			m.message = ""
+		}
+		return m, nil
+
+	case backendEventMsg:
+		if !msg.ok {
+			m.isInstalling = false
+			m.isRemoving = false
+			return m, nil
+		}
+
+		ev := msg.ev
+		progressCmd := m.progressBar.SetPercent(ev.Percent / 100)
+		if ev.Log != "" {
+			m.message = ev.Log
+		}
+
+		if ev.Done {
+			m.isInstalling = false
+			m.isRemoving = false
+			if ev.Err != nil {
+				m.message = fmt.Sprintf("%s failed: %v", msg.action, ev.Err)
+			} else {
+				m.message = fmt.Sprintf("%s %s complete.", msg.action, ev.Package)
+				m.recordTransaction(msg.action, Package{name: ev.Package, source: msg.source}, db.Explicit)
+			}
+			return m, progressCmd
+		}
+
+		return m, tea.Batch(progressCmd, waitForEvent(msg.ch, msg.action, msg.source))
+
+	case progress.FrameMsg:
+		progressModel, progressCmd := m.progressBar.Update(msg)
+		m.progressBar = progressModel.(progress.Model)
+		return m, progressCmd
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
+// recordTransaction records a single install/remove against the local
+// package database under its own transaction, so a plain 'i'/'r' from the
+// package list is always tracked as an Explicit install even though a
+// dependency-ordered batch (installOrderCmd) may later add it as a
+// prerequisite of something else.
+func (m *model) recordTransaction(action string, pkg Package, reason db.Reason) {
+	if m.installDB == nil {
+		return
+	}
+	txID := db.NewTransactionID()
+	if action == "remove" {
+		m.installDB.RecordRemove(pkg.name, string(pkg.source), txID)
+	} else {
+		m.installDB.RecordInstall(pkg.name, string(pkg.source), "", reason, txID)
+	}
+	if err := m.installDB.Save(); err != nil {
+		m.message = fmt.Sprintf("%s %s complete, but saving package db failed: %v", action, pkg.name, err)
+	}
+}
+
 func (m *model) updateList() {
 	var items []list.Item
 	for _, p := range m.packages {
@@ -275,14 +531,36 @@ func (m model) View() string {
 	}
 
 	input := inputStyle.Render(m.textInput.View())
-	pkgList := listStyle.Render(m.pkgList.View())
+
+	var body string
+	switch {
+	case m.isInstalling || m.isRemoving:
+		body = listStyle.Render(m.progressBar.View())
+	case m.pendingPlan != nil:
+		body = planBoxStyle.Render(renderPlan(m.pendingPlan.plan))
+	case m.pendingDeps != nil:
+		body = planBoxStyle.Render(renderDepOrder(m.pendingDeps))
+	case m.pendingOrphans != nil:
+		body = planBoxStyle.Render(renderOrphans(m.pendingOrphans))
+	default:
+		body = listStyle.Render(m.pkgList.View())
+	}
 
 	var help string
-	switch m.mode {
+	switch {
+	case m.pendingPlan != nil:
+		help = "Review the transaction above. Press Enter to confirm, Esc to cancel."
+	case m.pendingDeps != nil:
+		help = "Review the resolved install order above. Press Enter to install, Esc to cancel."
+	case m.pendingOrphans != nil:
+		help = "Review the orphaned packages above. Press Enter to remove them all, Esc to cancel."
+	default:
+		switch m.mode {
 		case Normal:
-			help = "Press q to exit, e to edit query, Enter to search, a/s/f/l to switch source (APT/SNAP/FLATPAK/ALL), i to install, r to remove, j/k or arrows to navigate."
+			help = "Press q to exit, e to edit query, Enter to search, a/s/f/l to switch source (APT/SNAP/FLATPAK/ALL), i to plan+install, r to plan+remove, space to multi-select, D to resolve deps, o to list orphans, j/k or arrows to navigate."
 		case Editing:
 			help = "Press Esc to cancel, Enter to confirm editing."
+		}
 	}
 
 	if m.message != "" {
@@ -291,7 +569,124 @@ func (m model) View() string {
 
 	help = helpStyle.Render(help)
 
-	return lipgloss.JoinVertical(lipgloss.Left, input, pkgList, help)
+	return lipgloss.JoinVertical(lipgloss.Left, input, body, help)
+}
+
+// renderPlan formats a TransactionPlan into the review pane shown before a
+// transaction is confirmed, color-coding installs, upgrades and removals.
+func renderPlan(p *plan.TransactionPlan) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Transaction plan [%s]\n", p.Source)
+
+	for _, c := range p.Installing {
+		b.WriteString(installStyle.Render(fmt.Sprintf("  + install %s (%s) [%s/%s]", c.Name, c.NewVersion, c.Repo, c.Arch)) + "\n")
+	}
+	for _, c := range p.Upgrading {
+		b.WriteString(upgradeStyle.Render(fmt.Sprintf("  ^ upgrade %s (%s -> %s) [%s/%s]", c.Name, c.CurrentVersion, c.NewVersion, c.Repo, c.Arch)) + "\n")
+	}
+	for _, c := range p.Removing {
+		b.WriteString(removeStyle.Render(fmt.Sprintf("  - remove %s (%s)", c.Name, c.CurrentVersion)) + "\n")
+	}
+
+	if len(p.Installing) == 0 && len(p.Upgrading) == 0 && len(p.Removing) == 0 {
+		b.WriteString("  (no changes)\n")
+	}
+
+	b.WriteString(planSizeStyle.Render(fmt.Sprintf("\nDownload: %s   Installed size: %s", p.DownloadSize, p.InstalledSize)))
+
+	return b.String()
+}
+
+// renderDepOrder formats a resolved dependency graph in install order:
+// build-time deps first, then runtime deps, then the community scripts and
+// their make-deps that needed them staged.
+func renderDepOrder(order *dep.DepOrder) string {
+	var b strings.Builder
+
+	b.WriteString("Resolved install order\n")
+
+	groups := []struct {
+		label string
+		pkgs  []dep.Package
+	}{
+		{"Build deps", order.RepoMake},
+		{"Repo", order.Repo},
+		{"Community make deps", order.Make},
+		{"Community", order.Community},
+	}
+	for _, g := range groups {
+		if len(g.pkgs) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "  %s:\n", g.label)
+		for _, p := range g.pkgs {
+			b.WriteString(installStyle.Render(fmt.Sprintf("    + %s", p.Name)) + "\n")
+		}
+	}
+
+	if len(order.Missing) > 0 {
+		b.WriteString(removeStyle.Render("  Missing/unresolved:") + "\n")
+		for _, name := range order.Missing {
+			b.WriteString(removeStyle.Render(fmt.Sprintf("    ? %s", name)) + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// renderOrphans formats packages hpm installed only as dependencies that
+// nothing explicit needs anymore, analogous to `pacman -Qtd`.
+func renderOrphans(orphans []db.Record) string {
+	var b strings.Builder
+
+	b.WriteString("Orphaned packages\n")
+	for _, rec := range orphans {
+		b.WriteString(removeStyle.Render(fmt.Sprintf("  - %s [%s]", rec.Name, rec.Source)) + "\n")
+	}
+
+	return b.String()
+}
+
+// removeOrphansCmd removes every orphan through its backend, recording
+// each removal as its own step of one shared transaction.
+func removeOrphansCmd(orphans []db.Record, installDB *db.DB) tea.Cmd {
+	return func() tea.Msg {
+		txID := db.NewTransactionID()
+		var lines []string
+		for _, rec := range orphans {
+			b, ok := backend.Get(rec.Source)
+			if !ok {
+				lines = append(lines, fmt.Sprintf("no backend registered for source %s", rec.Source))
+				continue
+			}
+			ch, err := b.Remove(context.Background(), backend.Package{Name: rec.Name}, backend.InstallOptions{})
+			if err != nil {
+				lines = append(lines, fmt.Sprintf("failed to remove %s: %v", rec.Name, err))
+				continue
+			}
+			var lastErr error
+			for ev := range ch {
+				if ev.Err != nil {
+					lastErr = ev.Err
+				}
+			}
+			if lastErr != nil {
+				lines = append(lines, fmt.Sprintf("failed to remove %s: %v", rec.Name, lastErr))
+				continue
+			}
+			if installDB != nil {
+				installDB.RecordRemove(rec.Name, rec.Source, txID)
+			}
+			lines = append(lines, fmt.Sprintf("Removed orphan %s", rec.Name))
+		}
+		if installDB != nil {
+			if err := installDB.Save(); err != nil {
+				lines = append(lines, fmt.Sprintf("warning: saving package db failed: %v", err))
+			}
+		}
+		return removeResult{output: strings.Join(lines, "\n"), err: nil}
+	}
 }
 
 func tick() tea.Cmd {
@@ -307,138 +702,339 @@ func searchCmd(query string) tea.Cmd {
 	}
 }
 
+// searchPackages fans a query out across every registered backend instead
+// of hardcoding one exec.Command per source; a backend erroring (e.g. not
+// installed on this system) just contributes no results.
 func searchPackages(query string) ([]Package, error) {
 	var pkgs []Package
+	for _, b := range backend.Enabled() {
+		found, err := b.Search(query)
+		if err != nil {
+			continue
+		}
+		for _, p := range found {
+			pkgs = append(pkgs, Package{name: p.Name, source: Source(b.Name()), desc: p.Desc})
+		}
+	}
+	return pkgs, nil
+}
 
-	// Search APT
-	cmd := exec.Command("apt-cache", "search", "--names-only", query)
-	out, _ := cmd.Output() // Ignore error, proceed if possible
-	if len(out) > 0 {
-		lines := strings.Split(string(out), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line == "" {
-				continue
-			}
-			parts := strings.SplitN(line, " - ", 2)
-			if len(parts) == 2 {
-				pkgs = append(pkgs, Package{
-					name:   strings.TrimSpace(parts[0]),
-					      source: Apt,
-					      desc:   strings.TrimSpace(parts[1]),
-				})
-			}
+func planCmd(pkg Package, action string) tea.Cmd {
+	return func() tea.Msg {
+		p, err := buildPlan(pkg, action)
+		return planResult{plan: p, target: pkg, action: action, err: err}
+	}
+}
+
+func buildPlan(pkg Package, action string) (*plan.TransactionPlan, error) {
+	remove := action == "remove"
+	switch pkg.source {
+	case Apt:
+		return plan.SimulateApt([]string{pkg.name}, remove)
+	case Snap:
+		return plan.SimulateSnap(pkg.name, remove)
+	case Flatpak:
+		return plan.SimulateFlatpak(pkg.name, remove)
+	case Source("COMMUNITY"):
+		return plan.SimulateCommunity(pkg.name, remove), nil
+	default:
+		return nil, fmt.Errorf("no simulator for source %s", pkg.source)
+	}
+}
+
+func resolveCmd(targets []Package) tea.Cmd {
+	return func() tea.Msg {
+		depTargets := make([]dep.Package, 0, len(targets))
+		for _, p := range targets {
+			depTargets = append(depTargets, toDepPackage(p))
 		}
+		order, err := dep.Resolve(depTargets)
+		return depResult{order: order, targets: targets, err: err}
+	}
+}
+
+// installOrderCmd installs a resolved DepOrder bucket by bucket. targets
+// are the packages the user originally selected (Space) before resolving
+// deps; everything else in the order is there only because targets need
+// it, so it's recorded as a Dependency install rather than Explicit.
+func installOrderCmd(order *dep.DepOrder, targets []Package, installDB *db.DB) tea.Cmd {
+	return func() tea.Msg {
+		lines, err := runInstallOrder(order, targets, installDB)
+		return installResult{output: strings.Join(lines, "\n"), err: err}
 	}
+}
 
-	// Search Snap
-	cmd = exec.Command("snap", "find", query)
-	out, _ = cmd.Output()
-	if len(out) > 0 {
-		lines := strings.Split(string(out), "\n")
-		start := 0
-		if len(lines) > 0 && strings.Contains(lines[0], "Name") {
-			start = 1
+// runInstallOrder installs a resolved DepOrder bucket by bucket, blocking
+// until done. It backs both the interactive installOrderCmd and the
+// non-interactive `-Ss` batch mode, and returns as many progress lines as
+// it managed before any error.
+func runInstallOrder(order *dep.DepOrder, targets []Package, installDB *db.DB) ([]string, error) {
+	explicit := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		explicit[t.name] = true
+	}
+
+	txID := db.NewTransactionID()
+	record := func(name, source string) {
+		if installDB == nil {
+			return
 		}
-		for _, line := range lines[start:] {
-			line = strings.TrimSpace(line)
-			if line == "" {
-				continue
-			}
-			fields := strings.Fields(line)
-			if len(fields) >= 5 {
-				name := fields[0]
-				desc := strings.Join(fields[4:], " ")
-				pkgs = append(pkgs, Package{name: name, source: Snap, desc: desc})
-			}
+		reason := db.Dependency
+		if explicit[name] {
+			reason = db.Explicit
 		}
+		installDB.RecordInstall(name, source, "", reason, txID)
 	}
 
-	// Search Flatpak
-	cmd = exec.Command("flatpak", "search", query)
-	out, _ = cmd.Output()
-	if len(out) > 0 {
-		lines := strings.Split(string(out), "\n")
-		start := 0
-		if len(lines) > 0 && strings.Contains(lines[0], "Name") {
-			start = 1
-		}
-		for _, line := range lines[start:] {
-			line = strings.TrimSpace(line)
-			if line == "" {
-				continue
-			}
-			parts := strings.Split(line, "\t")
-			if len(parts) >= 3 {
-				name := parts[2]
-				desc := fmt.Sprintf("%s - %s", parts[0], parts[1])
-				pkgs = append(pkgs, Package{name: name, source: Flatpak, desc: desc})
-			}
+	// p.Backend carries the real backend (apt/snap/flatpak) a dep.Package
+	// was resolved against; it defaults to apt for anything discovered by
+	// walking apt's own dependency graph, never for a user-selected target.
+	backendSource := func(p dep.Package) Source {
+		if p.Backend == "" {
+			return Apt
 		}
+		return Source(p.Backend)
 	}
 
-	return pkgs, nil
+	var lines []string
+	for _, p := range order.RepoMake {
+		src := backendSource(p)
+		out, err := installPackage(Package{name: p.Name, source: src})
+		if err != nil {
+			return lines, err
+		}
+		record(p.Name, string(src))
+		lines = append(lines, out)
+	}
+	for _, p := range order.Repo {
+		src := backendSource(p)
+		out, err := installPackage(Package{name: p.Name, source: src})
+		if err != nil {
+			return lines, err
+		}
+		record(p.Name, string(src))
+		lines = append(lines, out)
+	}
+	for _, p := range order.Community {
+		lines = append(lines, fmt.Sprintf("%s is a community package; run `hpm community install %s`", p.Name, p.Name))
+	}
+	if installDB != nil {
+		if err := installDB.Save(); err != nil {
+			lines = append(lines, fmt.Sprintf("warning: saving package db failed: %v", err))
+		}
+	}
+	return lines, nil
 }
 
-func installCmd(pkg Package) tea.Cmd {
+// startTransaction kicks off an Install or Remove on the backend matching
+// pkg.source and starts streaming its Event channel into the model via
+// waitForEvent, instead of blocking the update loop for the whole transfer.
+func startTransaction(pkg Package, action string) tea.Cmd {
 	return func() tea.Msg {
-		output, err := installPackage(pkg)
-		return installResult{output: output, err: err}
+		b, ok := backend.Get(string(pkg.source))
+		if !ok {
+			err := fmt.Errorf("no backend registered for source %s", pkg.source)
+			if action == "remove" {
+				return removeResult{err: err}
+			}
+			return installResult{err: err}
+		}
+
+		bp := backend.Package{Name: pkg.name}
+		var ch <-chan backend.Event
+		var err error
+		if action == "remove" {
+			ch, err = b.Remove(context.Background(), bp, backend.InstallOptions{})
+		} else {
+			ch, err = b.Install(context.Background(), bp, backend.InstallOptions{})
+		}
+		if err != nil {
+			if action == "remove" {
+				return removeResult{err: err}
+			}
+			return installResult{err: err}
+		}
+
+		ev, ok := <-ch
+		return backendEventMsg{ch: ch, ev: ev, ok: ok, action: action, source: pkg.source}
 	}
 }
 
+// installPackage drains a backend install to completion; used by the
+// dependency-ordered batch install where packages go in one after another
+// rather than streaming live into the UI.
 func installPackage(p Package) (string, error) {
-	var cmd *exec.Cmd
-	switch p.source {
-		case Apt:
-			cmd = exec.Command("sudo", "apt", "install", "-y", p.name)
-		case Snap:
-			cmd = exec.Command("sudo", "snap", "install", p.name)
-		case Flatpak:
-			cmd = exec.Command("sudo", "flatpak", "install", "--assumeyes", p.name)
-		default:
-			return "Invalid source", nil
-	}
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("failed to install %s from %s: %v\n%s", p.name, p.source, err, string(out))
+	b, ok := backend.Get(string(p.source))
+	if !ok {
+		return "", fmt.Errorf("no backend registered for source %s", p.source)
 	}
-	return fmt.Sprintf("Installed %s from %s", p.name, p.source), nil
-}
 
-func removeCmd(pkg Package) tea.Cmd {
-	return func() tea.Msg {
-		output, err := removePackage(pkg)
-		return removeResult{output: output, err: err}
+	ch, err := b.Install(context.Background(), backend.Package{Name: p.name}, backend.InstallOptions{})
+	if err != nil {
+		return "", err
 	}
-}
 
-func removePackage(p Package) (string, error) {
-	var cmd *exec.Cmd
-	switch p.source {
-		case Apt:
-			cmd = exec.Command("sudo", "apt", "remove", "-y", p.name)
-		case Snap:
-			cmd = exec.Command("sudo", "snap", "remove", p.name)
-		case Flatpak:
-			cmd = exec.Command("sudo", "flatpak", "uninstall", "--assumeyes", p.name)
-		default:
-			return "Invalid source", nil
+	var lastErr error
+	for ev := range ch {
+		if ev.Err != nil {
+			lastErr = ev.Err
+		}
 	}
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("failed to remove %s from %s: %v\n%s", p.name, p.source, err, string(out))
+	if lastErr != nil {
+		return "", fmt.Errorf("failed to install %s from %s: %w", p.name, p.source, lastErr)
 	}
-	return fmt.Sprintf("Removed %s from %s", p.name, p.source), nil
+	return fmt.Sprintf("Installed %s from %s", p.name, p.source), nil
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "orphans":
+			runOrphans()
+			return
+		case "history":
+			runHistory()
+			return
+		case "rollback":
+			runRollback(os.Args[2:])
+			return
+		}
+	}
+
 	query := flag.String("query", "", "Initial search query")
+	ssQuery := flag.String("Ss", "", "non-interactive numbered search-and-install, yay-style")
 	flag.Parse()
 
+	if *ssQuery != "" {
+		runBatchSearch(*ssQuery)
+		return
+	}
+
 	p := tea.NewProgram(New(*query), tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
+
+// runOrphans implements `hpm orphans`: list, without removing, every
+// Dependency-reason apt package the local db no longer thinks anything
+// needs (see db.Orphans for why this is apt-only for now).
+func runOrphans() {
+	installDB, err := db.Load()
+	if err != nil {
+		fmt.Println("Failed to load package db:", err)
+		os.Exit(1)
+	}
+	orphans, err := installDB.Orphans()
+	if err != nil {
+		fmt.Println("Orphan scan failed:", err)
+		os.Exit(1)
+	}
+	if len(orphans) == 0 {
+		fmt.Println("No orphaned packages.")
+		return
+	}
+	for _, rec := range orphans {
+		fmt.Printf("%s [%s]\n", rec.Name, rec.Source)
+	}
+}
+
+// runHistory implements `hpm history`: list past transactions, newest last.
+func runHistory() {
+	installDB, err := db.Load()
+	if err != nil {
+		fmt.Println("Failed to load package db:", err)
+		os.Exit(1)
+	}
+	if len(installDB.Transactions) == 0 {
+		fmt.Println("No transactions recorded.")
+		return
+	}
+	for _, tx := range installDB.Transactions {
+		names := make([]string, len(tx.Packages))
+		for i, p := range tx.Packages {
+			names[i] = fmt.Sprintf("%s(%s)", p.Name, p.Source)
+		}
+		fmt.Printf("%s  %-7s  %s  %s\n", tx.ID, tx.Action, tx.Timestamp.Format(time.RFC3339), strings.Join(names, ", "))
+	}
+}
+
+// runRollback implements `hpm rollback <txid>`: undoes a transaction by
+// installing what it removed and removing what it installed.
+func runRollback(args []string) {
+	if len(args) != 1 {
+		fmt.Println("Usage: hpm rollback <txid>")
+		os.Exit(1)
+	}
+
+	installDB, err := db.Load()
+	if err != nil {
+		fmt.Println("Failed to load package db:", err)
+		os.Exit(1)
+	}
+	tx, ok := installDB.Transaction(args[0])
+	if !ok {
+		fmt.Println("No such transaction:", args[0])
+		os.Exit(1)
+	}
+
+	reverseAction := "remove"
+	if tx.Action == "remove" {
+		reverseAction = "install"
+	}
+
+	for _, txPkg := range tx.Packages {
+		b, ok := backend.Get(txPkg.Source)
+		if !ok {
+			fmt.Printf("no backend registered for source %s, skipping %s\n", txPkg.Source, txPkg.Name)
+			continue
+		}
+
+		var ch <-chan backend.Event
+		var err error
+		bp := backend.Package{Name: txPkg.Name}
+		if reverseAction == "remove" {
+			ch, err = b.Remove(context.Background(), bp, backend.InstallOptions{})
+		} else {
+			ch, err = b.Install(context.Background(), bp, backend.InstallOptions{})
+		}
+		if err != nil {
+			fmt.Printf("%s %s failed: %v\n", reverseAction, txPkg.Name, err)
+			continue
+		}
+
+		var lastErr error
+		for ev := range ch {
+			if ev.Log != "" {
+				fmt.Println(ev.Log)
+			}
+			if ev.Err != nil {
+				lastErr = ev.Err
+			}
+		}
+		if lastErr != nil {
+			fmt.Printf("%s %s failed: %v\n", reverseAction, txPkg.Name, lastErr)
+			continue
+		}
+
+		if reverseAction == "remove" {
+			installDB.RecordRemove(txPkg.Name, txPkg.Source, tx.ID+"-rollback")
+		} else {
+			// txPkg.Reason is whatever the package's reason was at the
+			// moment the transaction being rolled back removed it; fall
+			// back to Dependency only for transactions recorded before
+			// RecordRemove started carrying it.
+			reason := txPkg.Reason
+			if reason == "" {
+				reason = db.Dependency
+			}
+			installDB.RecordInstall(txPkg.Name, txPkg.Source, "", reason, tx.ID+"-rollback")
+		}
+		fmt.Printf("%s %s: done\n", reverseAction, txPkg.Name)
+	}
+
+	if err := installDB.Save(); err != nil {
+		fmt.Println("warning: saving package db failed:", err)
+	}
+}